@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// bulkConcurrency bounds how many stories a bulk update works on at once.
+const bulkConcurrency = 4
+
+// BulkOperations lists the operations BulkUpdateStories supports.
+var BulkOperations = []string{"set_state", "add_label", "remove_label", "add_owner", "remove_owner", "set_estimate"}
+
+// BulkOperation is one change applied to every story in a bulk update.
+// Only the field matching Kind is used.
+type BulkOperation struct {
+	Kind     string
+	State    string
+	Label    string
+	OwnerID  int
+	Estimate int
+}
+
+type BulkResult struct {
+	StoryID int
+	Err     error
+}
+
+func (op BulkOperation) validate() error {
+	switch op.Kind {
+	case "set_state":
+		if !slices.Contains(StoryStates, op.State) {
+			return fmt.Errorf("invalid story state %q: must be one of %s", op.State, strings.Join(StoryStates, ", "))
+		}
+	case "add_label", "remove_label":
+		if op.Label == "" {
+			return fmt.Errorf("%s requires a label", op.Kind)
+		}
+	case "add_owner", "remove_owner":
+		if op.OwnerID == 0 {
+			return fmt.Errorf("%s requires an owner", op.Kind)
+		}
+	case "set_estimate":
+		if op.Estimate < 0 {
+			return fmt.Errorf("estimate cannot be negative")
+		}
+	default:
+		return fmt.Errorf("invalid operation %q: must be one of %s", op.Kind, strings.Join(BulkOperations, ", "))
+	}
+	return nil
+}
+
+func (op BulkOperation) usesWebSession() bool {
+	switch op.Kind {
+	case "add_label", "remove_label", "add_owner", "remove_owner":
+		return true
+	}
+	return false
+}
+
+func (op BulkOperation) apply(ctx context.Context, c *Client, projectID, storyID int) error {
+	var err error
+	switch op.Kind {
+	case "set_state":
+		_, err = c.UpdateStoryState(ctx, projectID, storyID, op.State)
+	case "set_estimate":
+		estimate := op.Estimate
+		_, err = c.UpdateStory(ctx, projectID, storyID, StoryUpdate{Estimate: &estimate})
+	case "add_label":
+		_, err = c.web.addLabel(ctx, storyID, projectID, op.Label)
+	case "remove_label":
+		_, err = c.web.removeLabel(ctx, storyID, projectID, op.Label)
+	case "add_owner":
+		_, err = c.web.addOwner(ctx, storyID, projectID, op.OwnerID)
+	case "remove_owner":
+		_, err = c.web.removeOwner(ctx, storyID, projectID, op.OwnerID)
+	}
+	return err
+}
+
+// BulkUpdateStories applies op to every story in storyIDs, a few at a time,
+// and reports a result per story instead of stopping at the first failure.
+// A story listed more than once is updated and reported once, at its first
+// position, so two workers never race on the same story.
+// Operations that need the web session log in once for the whole batch and
+// re-login at most once if the session expires partway through. The workers
+// share the session under its read lock, so other web session calls, and
+// their logins, wait until the batch is done. Stories not yet started when
+// ctx is cancelled report ctx.Err().
+func (c *Client) BulkUpdateStories(ctx context.Context, projectID int, storyIDs []int, op BulkOperation) ([]BulkResult, error) {
+	if err := op.validate(); err != nil {
+		return nil, err
+	}
+	storyIDs = uniqueIDs(storyIDs)
+
+	var wc *WebClient
+	if op.usesWebSession() {
+		wc = c.web
+		wc.mu.Lock()
+		err := wc.ensureLoggedIn(ctx)
+		wc.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]BulkResult, len(storyIDs))
+	run := func(indexes []int) {
+		if wc != nil {
+			// Logins take the write lock, so none can swap the session's
+			// cookies or CSRF token while a worker is mid-request
+			wc.mu.RLock()
+			defer wc.mu.RUnlock()
+		}
+		sem := make(chan struct{}, bulkConcurrency)
+		var wg sync.WaitGroup
+		for _, i := range indexes {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i] = BulkResult{StoryID: storyIDs[i], Err: ctx.Err()}
+				continue
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				results[i] = BulkResult{StoryID: storyIDs[i], Err: op.apply(ctx, c, projectID, storyIDs[i])}
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	all := make([]int, len(storyIDs))
+	for i := range storyIDs {
+		all[i] = i
+	}
+	run(all)
+
+	if wc == nil {
+		return results, nil
+	}
+	var expired []int
+	for i, r := range results {
+		if isSessionExpired(r.Err) {
+			expired = append(expired, i)
+		}
+	}
+	if len(expired) == 0 {
+		return results, nil
+	}
+
+	// Session expired mid-batch, re-login once and retry the affected stories
+	wc.mu.Lock()
+	wc.loggedIn = false
+	err := wc.ensureLoggedIn(ctx)
+	wc.mu.Unlock()
+	if err != nil {
+		for _, i := range expired {
+			results[i].Err = err
+		}
+		return results, nil
+	}
+	run(expired)
+	return results, nil
+}
+
+// uniqueIDs returns ids without repeats, keeping the first occurrence of each.
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	out := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
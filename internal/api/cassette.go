@@ -0,0 +1,309 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Cassette modes for NewCassetteTransport.
+const (
+	CassetteRecord = "record"
+	CassetteReplay = "replay"
+)
+
+// redacted replaces secrets in recorded interactions.
+const redacted = "REDACTED"
+
+// redactedHeaders carry credentials in either direction.
+var redactedHeaders = []string{"X-Trackertoken", "Authorization", "Cookie", "Set-Cookie", "X-Csrf-Token"}
+
+// redactedFields are form fields posted to /login that carry credentials.
+var redactedFields = []string{"authenticity_token", "user[login]", "user[password]", "user[otp_attempt]"}
+
+// redactedJSONKeys are object keys whose values are secrets in JSON bodies,
+// such as the api_token in the v5 /me response. They match at any depth.
+var redactedJSONKeys = []string{"api_token", "token", "password", "otp", "otp_attempt", "authenticity_token", "csrf_token"}
+
+// interaction is one recorded request and response, stored as a JSON file
+// in the cassette directory.
+type interaction struct {
+	Request struct {
+		Method string      `json:"method"`
+		URL    string      `json:"url"`
+		Header http.Header `json:"header"`
+		Body   string      `json:"body,omitempty"`
+	} `json:"request"`
+	Response struct {
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header"`
+		Body       string      `json:"body,omitempty"`
+	} `json:"response"`
+}
+
+// NewCassetteTransport returns a transport that records every request and
+// response passing through base to dir, or, in replay mode, answers requests
+// from a previous recording without touching the network. Tokens, passwords,
+// cookies and CSRF tokens are redacted before anything is written.
+func NewCassetteTransport(mode, dir string, base http.RoundTripper) (http.RoundTripper, error) {
+	switch mode {
+	case CassetteRecord:
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("create cassette dir: %w", err)
+		}
+		existing, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("list cassette dir: %w", err)
+		}
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		return &cassetteRecorder{base: base, dir: dir, seq: len(existing)}, nil
+	case CassetteReplay:
+		return loadCassette(dir)
+	default:
+		return nil, fmt.Errorf("invalid cassette mode %q: must be %s or %s", mode, CassetteRecord, CassetteReplay)
+	}
+}
+
+type cassetteRecorder struct {
+	base http.RoundTripper
+	dir  string
+
+	mu  sync.Mutex
+	seq int
+}
+
+func (r *cassetteRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var it interaction
+	it.Request.Method = req.Method
+	it.Request.URL = cassetteKeyURL(req.URL)
+	it.Request.Header = redactHeader(req.Header)
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		it.Request.Body = redactBody(req.Header.Get("Content-Type"), body)
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	it.Response.StatusCode = resp.StatusCode
+	it.Response.Header = redactHeader(resp.Header)
+	it.Response.Body = redactBody(resp.Header.Get("Content-Type"), body)
+
+	if err := r.save(req, it); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+func (r *cassetteRecorder) save(req *http.Request, it interaction) error {
+	data, err := json.MarshalIndent(it, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal cassette interaction: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	slug := strings.Trim(unsafePathChars.ReplaceAllString(req.URL.Path, "_"), "_")
+	name := fmt.Sprintf("%05d-%s-%s.json", r.seq, req.Method, slug)
+	if err := os.WriteFile(filepath.Join(r.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("write cassette: %w", err)
+	}
+	return nil
+}
+
+// cassettePlayer serves recorded responses. Requests are matched on method,
+// path and query; repeated requests get the recorded responses in order,
+// and the last one again once those run out.
+type cassettePlayer struct {
+	mu     sync.Mutex
+	byKey  map[string][]interaction
+	served map[string]int
+}
+
+func loadCassette(dir string) (*cassettePlayer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("list cassette dir: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("cassette dir %s has no recordings", dir)
+	}
+	slices.Sort(files)
+	p := &cassettePlayer{byKey: map[string][]interaction{}, served: map[string]int{}}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("read cassette: %w", err)
+		}
+		var it interaction
+		if err := json.Unmarshal(data, &it); err != nil {
+			return nil, fmt.Errorf("parse cassette %s: %w", filepath.Base(f), err)
+		}
+		key := it.Request.Method + " " + it.Request.URL
+		p.byKey[key] = append(p.byKey[key], it)
+	}
+	return p, nil
+}
+
+func (p *cassettePlayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	key := req.Method + " " + cassetteKeyURL(req.URL)
+
+	p.mu.Lock()
+	recorded := p.byKey[key]
+	if len(recorded) == 0 {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("cassette has no recorded response for %s", key)
+	}
+	i := min(p.served[key], len(recorded)-1)
+	p.served[key]++
+	p.mu.Unlock()
+
+	it := recorded[i]
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", it.Response.StatusCode, http.StatusText(it.Response.StatusCode)),
+		StatusCode:    it.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        it.Response.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(it.Response.Body)),
+		ContentLength: int64(len(it.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// cassetteKeyURL is the part of a URL requests are matched on: the host is
+// left out so a recording can be replayed against any base URL.
+func cassetteKeyURL(u *url.URL) string {
+	return u.RequestURI()
+}
+
+func redactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range redactedHeaders {
+		values := out.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		for i, v := range values {
+			// Keep cookie names and attributes so recordings stay readable
+			values[i] = redactCookie(name, v)
+		}
+		out[http.CanonicalHeaderKey(name)] = values
+	}
+	return out
+}
+
+var cookieValueRegex = regexp.MustCompile(`(^|;\s*)([^=;\s]+)=[^;]*`)
+
+func redactCookie(header, v string) string {
+	switch header {
+	case "Cookie":
+		return cookieValueRegex.ReplaceAllString(v, "${1}${2}="+redacted)
+	case "Set-Cookie":
+		name, rest, _ := strings.Cut(v, "=")
+		if _, attrs, ok := strings.Cut(rest, ";"); ok {
+			return name + "=" + redacted + ";" + attrs
+		}
+		return name + "=" + redacted
+	}
+	return redacted
+}
+
+// csrfMetaRegex matches the CSRF token in the login page, keeping the text
+// around it so replayed pages still parse.
+var csrfMetaRegex = regexp.MustCompile(`(csrf-token[^>]*content=")[^"]*(")`)
+
+func redactBody(contentType string, body []byte) string {
+	switch {
+	case strings.HasPrefix(contentType, "application/json"):
+		return redactJSON(body)
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return redacted
+		}
+		for _, f := range redactedFields {
+			if form.Has(f) {
+				form.Set(f, redacted)
+			}
+		}
+		return form.Encode()
+	}
+	return csrfMetaRegex.ReplaceAllString(string(body), "${1}"+redacted+"${2}")
+}
+
+// redactJSON replaces the values of redactedJSONKeys in a JSON body. A body
+// without secrets is kept byte for byte; one that doesn't parse is dropped
+// rather than risk writing a secret.
+func redactJSON(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return string(body)
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return redacted
+	}
+	if !redactJSONValue(v) {
+		return string(body)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return redacted
+	}
+	return string(out)
+}
+
+// redactJSONValue redacts secrets in v in place and reports whether it
+// found any.
+func redactJSONValue(v any) bool {
+	found := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if slices.Contains(redactedJSONKeys, strings.ToLower(k)) {
+				v[k] = redacted
+				found = true
+			} else if redactJSONValue(child) {
+				found = true
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if redactJSONValue(child) {
+				found = true
+			}
+		}
+	}
+	return found
+}
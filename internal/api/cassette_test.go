@@ -0,0 +1,128 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+	"github.com/MelianLabs/litetracker-mcp/internal/fake"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	srv := fake.NewServer()
+	pid := srv.AddProject("Web")
+	st := srv.AddStory(pid, api.Story{Title: "Checkout"})
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	recorder, err := api.NewCassetteTransport(api.CassetteRecord, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := srv.Options()
+	opts.Transport = recorder
+	live := api.New(opts)
+	if _, err := live.GetStory(ctx, pid, st.ID); err != nil {
+		t.Fatal(err)
+	}
+	posted, err := live.WebPostComment(ctx, pid, st.ID, "recorded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) == 0 {
+		t.Fatal("nothing recorded")
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{fake.Token, fake.Password, fake.Email} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("%s contains secret %q", filepath.Base(f), secret)
+			}
+		}
+		if strings.Contains(string(data), "_litetracker_session=") && !strings.Contains(string(data), "_litetracker_session=REDACTED") {
+			t.Errorf("%s contains an unredacted session cookie", filepath.Base(f))
+		}
+	}
+
+	player, err := api.NewCassetteTransport(api.CassetteReplay, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Transport = player
+	replayed := api.New(opts)
+	got, err := replayed.GetStory(ctx, pid, st.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Checkout" {
+		t.Errorf("replayed title = %q, want Checkout", got.Title)
+	}
+	comment, err := replayed.WebPostComment(ctx, pid, st.ID, "recorded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.ID != posted.ID {
+		t.Errorf("replayed comment ID = %d, want %d", comment.ID, posted.ID)
+	}
+	if _, err := replayed.GetStory(ctx, pid, 999); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("unrecorded request error = %v", err)
+	}
+}
+
+func TestCassetteRedactsJSONSecrets(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	dir := t.TempDir()
+
+	recorder, err := api.NewCassetteTransport(api.CassetteRecord, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := srv.Options()
+	opts.Transport = recorder
+	me, err := api.New(opts).GetMe(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if me.ID != fake.UserID {
+		t.Errorf("recorded /me id = %d, want %d", me.ID, fake.UserID)
+	}
+	// A JSON request body carrying a password, whatever the endpoint makes of it
+	hc := &http.Client{Transport: recorder}
+	resp, err := hc.Post(srv.URL+"/services/v5/sessions", "application/json",
+		strings.NewReader(`{"user":{"login":"dev","password":"hunter2"},"id":12345678901234567}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("recorded %d files, want 2", len(files))
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{fake.Token, "hunter2"} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("%s contains secret %q:\n%s", filepath.Base(f), secret, data)
+			}
+		}
+	}
+	data, _ := os.ReadFile(files[1])
+	if !strings.Contains(string(data), "12345678901234567") {
+		t.Errorf("redaction mangled other JSON values:\n%s", data)
+	}
+}
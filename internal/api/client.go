@@ -1,36 +1,118 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
-	"time"
-
-	"github.com/MelianLabs/litetracker-mcp/internal/config"
 )
 
-var client = &http.Client{Timeout: 30 * time.Second}
+// Options configures a Client. Token is required for the v5 API; Email,
+// Password and UserID are only needed for the web session write tools.
+type Options struct {
+	Token    string
+	BaseURL  string
+	WebURL   string
+	Email    string
+	Password string
+	UserID   int
+	// TOTPSecret is the base32 secret of the account's authenticator app,
+	// for completing two-factor logins.
+	TOTPSecret string
+	// SessionFile is where the web session's cookies are saved after a
+	// login and restored from on startup. Empty disables persistence.
+	SessionFile string
 
-func request(method, path string, body io.Reader) (*http.Response, error) {
-	u := config.C.BaseURL + path
-	req, err := http.NewRequest(method, u, body)
-	if err != nil {
-		return nil, err
+	Retry RetryPolicy
+	// RateLimitRPS is the requests-per-second limit shared by the v5 and
+	// web session traffic of one Client. 0 disables rate limiting.
+	RateLimitRPS   float64
+	RateLimitBurst int
+
+	// Transport is the underlying HTTP transport, http.DefaultTransport if
+	// nil. Useful for pointing a Client at a fake server in tests.
+	Transport http.RoundTripper
+}
+
+// Client talks to one LiteTracker account, through both the token
+// authenticated v5 API and the web session used for writes the v5 API
+// does not support.
+type Client struct {
+	opts Options
+	http *http.Client
+	web  *WebClient
+}
+
+func New(opts Options) *Client {
+	if opts.BaseURL == "" {
+		opts.BaseURL = "https://app.litetracker.com/services/v5"
+	}
+	if opts.WebURL == "" {
+		opts.WebURL = "https://app.litetracker.com"
+	}
+	base := opts.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	// The v5 and web transports share one limiter so together they stay
+	// under the configured rate.
+	var limiter *tokenBucket
+	if opts.RateLimitRPS > 0 {
+		limiter = newTokenBucket(opts.RateLimitRPS, opts.RateLimitBurst)
+	}
+	// The transport times each request out itself, after the limiter
+	// wait, so the http.Clients have no Timeout of their own
+	transport := rateLimitedTransport{base: base, limiter: limiter, timeout: requestTimeout}
+
+	c := &Client{
+		opts: opts,
+		http: &http.Client{Transport: transport},
+	}
+	c.web = newWebClient(c, transport)
+	return c
+}
+
+// UserID returns the LiteTracker user ID the client acts as for web
+// session writes, or 0 if none was configured.
+func (c *Client) UserID() int {
+	return c.opts.UserID
+}
+
+func (c *Client) request(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	u := c.opts.BaseURL + path
+	// Buffer the body so it can be replayed on retry
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return nil, fmt.Errorf("read request body: %w", err)
+		}
 	}
-	req.Header.Set("X-TrackerToken", config.C.Token)
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := client.Do(req)
+	resp, err := c.doWithRetry(ctx, c.http, method, func() (*http.Request, error) {
+		var body io.Reader
+		if payload != nil {
+			body = bytes.NewReader(payload)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, u, body)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-TrackerToken", c.opts.Token)
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode >= 400 {
-		defer resp.Body.Close()
-		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("LiteTracker API %d: %s — %s", resp.StatusCode, resp.Status, string(b))
+		return nil, newAPIError(resp)
 	}
 	return resp, nil
 }
@@ -44,23 +126,33 @@ func decode[T any](resp *http.Response) (T, error) {
 	return result, nil
 }
 
-func GetMe() (Me, error) {
-	resp, err := request("GET", "/me", nil)
+func (c *Client) GetMe(ctx context.Context) (Me, error) {
+	resp, err := c.request(ctx, "GET", "/me", nil)
 	if err != nil {
 		return Me{}, err
 	}
 	return decode[Me](resp)
 }
 
-func ListProjects() ([]Project, error) {
-	resp, err := request("GET", "/projects", nil)
+func (c *Client) ListProjects(ctx context.Context) ([]Project, error) {
+	resp, err := c.request(ctx, "GET", "/projects", nil)
 	if err != nil {
 		return nil, err
 	}
 	return decode[[]Project](resp)
 }
 
-func ListStories(projectID int, opts ListStoriesOpts) ([]Story, error) {
+func (c *Client) GetProject(ctx context.Context, projectID int) (Project, error) {
+	resp, err := c.request(ctx, "GET", fmt.Sprintf("/projects/%d", projectID), nil)
+	if err != nil {
+		return Project{}, err
+	}
+	return decode[Project](resp)
+}
+
+// ListStoriesPage fetches a single page of stories starting at opts.Offset.
+// opts.Limit is the page size and defaults to 20.
+func (c *Client) ListStoriesPage(ctx context.Context, projectID int, opts ListStoriesOpts) ([]Story, Page, error) {
 	params := url.Values{}
 	if opts.Filter != "" {
 		params.Set("filter", opts.Filter)
@@ -85,71 +177,337 @@ func ListStories(projectID int, opts ListStoriesOpts) ([]Story, error) {
 		limit = 20
 	}
 	params.Set("limit", strconv.Itoa(limit))
+	if opts.Offset != 0 {
+		params.Set("offset", strconv.Itoa(opts.Offset))
+	}
 
-	resp, err := request("GET", fmt.Sprintf("/projects/%d/stories?%s", projectID, params.Encode()), nil)
+	resp, err := c.request(ctx, "GET", fmt.Sprintf("/projects/%d/stories?%s", projectID, params.Encode()), nil)
 	if err != nil {
-		return nil, err
+		return nil, Page{}, err
+	}
+	return decodePage[Story](resp, opts.Offset, limit)
+}
+
+// IterStories yields every story matching opts, from opts.Offset onwards,
+// fetching further pages as the caller ranges over them. opts.Limit is the
+// page size and defaults to 100.
+func (c *Client) IterStories(ctx context.Context, projectID int, opts ListStoriesOpts) iter.Seq2[Story, error] {
+	if opts.Limit == 0 {
+		opts.Limit = defaultPageSize
 	}
-	return decode[[]Story](resp)
+	return paginate(opts.Offset, func(offset int) ([]Story, Page, error) {
+		opts.Offset = offset
+		return c.ListStoriesPage(ctx, projectID, opts)
+	})
 }
 
-func GetStory(projectID, storyID int) (Story, error) {
-	resp, err := request("GET", fmt.Sprintf("/projects/%d/stories/%d", projectID, storyID), nil)
+// ListStories returns every story matching opts, across all pages.
+func (c *Client) ListStories(ctx context.Context, projectID int, opts ListStoriesOpts) ([]Story, error) {
+	return collect(c.IterStories(ctx, projectID, opts))
+}
+
+func (c *Client) GetStory(ctx context.Context, projectID, storyID int) (Story, error) {
+	resp, err := c.request(ctx, "GET", fmt.Sprintf("/projects/%d/stories/%d", projectID, storyID), nil)
 	if err != nil {
 		return Story{}, err
 	}
 	return decode[Story](resp)
 }
 
-func GetStoryComments(projectID, storyID int) ([]Comment, error) {
-	resp, err := request("GET", fmt.Sprintf("/projects/%d/stories/%d/comments", projectID, storyID), nil)
+// MoveStory repositions a story directly before or after another story.
+// Exactly one of beforeID and afterID must be non-zero.
+func (c *Client) MoveStory(ctx context.Context, projectID, storyID, beforeID, afterID int) (Story, error) {
+	if (beforeID == 0) == (afterID == 0) {
+		return Story{}, fmt.Errorf("exactly one of before_id and after_id must be set")
+	}
+	if beforeID == storyID || afterID == storyID {
+		return Story{}, fmt.Errorf("cannot move story %d relative to itself", storyID)
+	}
+	var update StoryUpdate
+	if beforeID != 0 {
+		update.BeforeID = &beforeID
+	} else {
+		update.AfterID = &afterID
+	}
+	return c.UpdateStory(ctx, projectID, storyID, update)
+}
+
+// ListPanelStories returns the stories in a panel (current, backlog or
+// icebox) in priority order.
+func (c *Client) ListPanelStories(ctx context.Context, projectID int, panel string) ([]Story, error) {
+	switch panel {
+	case "current", "backlog":
+		// The backlog can run to more iterations than the server returns
+		// by default, so every page is read
+		iterations, err := collect(paginate(0, func(offset int) ([]Iteration, Page, error) {
+			return c.ListIterationsPage(ctx, projectID, panel, offset, defaultPageSize)
+		}))
+		if err != nil {
+			return nil, err
+		}
+		var stories []Story
+		for _, it := range iterations {
+			stories = append(stories, it.Stories...)
+		}
+		return stories, nil
+	case "icebox":
+		return c.ListStories(ctx, projectID, ListStoriesOpts{State: "unscheduled"})
+	default:
+		return nil, fmt.Errorf("invalid panel %q: must be one of %s", panel, strings.Join(StoryPanels, ", "))
+	}
+}
+
+func (c *Client) GetStoryComments(ctx context.Context, projectID, storyID int) ([]Comment, error) {
+	resp, err := c.request(ctx, "GET", fmt.Sprintf("/projects/%d/stories/%d/comments", projectID, storyID), nil)
 	if err != nil {
 		return nil, err
 	}
 	return decode[[]Comment](resp)
 }
 
-func PostComment(projectID, storyID int, text string) (Comment, error) {
+func (c *Client) GetComment(ctx context.Context, projectID, storyID, commentID int) (Comment, error) {
+	resp, err := c.request(ctx, "GET", fmt.Sprintf("/projects/%d/stories/%d/comments/%d", projectID, storyID, commentID), nil)
+	if err != nil {
+		return Comment{}, err
+	}
+	return decode[Comment](resp)
+}
+
+func (c *Client) PostComment(ctx context.Context, projectID, storyID int, text string) (Comment, error) {
 	payload, err := json.Marshal(map[string]string{"text": text})
 	if err != nil {
 		return Comment{}, fmt.Errorf("marshal comment: %w", err)
 	}
 	body := strings.NewReader(string(payload))
-	resp, err := request("POST", fmt.Sprintf("/projects/%d/stories/%d/comments", projectID, storyID), body)
+	resp, err := c.request(ctx, "POST", fmt.Sprintf("/projects/%d/stories/%d/comments", projectID, storyID), body)
 	if err != nil {
 		return Comment{}, err
 	}
 	return decode[Comment](resp)
 }
 
-func CreateStory(projectID int, params map[string]any) (Story, error) {
+func (c *Client) CreateStory(ctx context.Context, projectID int, params map[string]any) (Story, error) {
 	payload, err := json.Marshal(params)
 	if err != nil {
 		return Story{}, fmt.Errorf("marshal story: %w", err)
 	}
 	body := strings.NewReader(string(payload))
-	resp, err := request("POST", fmt.Sprintf("/projects/%d/stories", projectID), body)
+	resp, err := c.request(ctx, "POST", fmt.Sprintf("/projects/%d/stories", projectID), body)
+	if err != nil {
+		return Story{}, err
+	}
+	return decode[Story](resp)
+}
+
+func (c *Client) UpdateStory(ctx context.Context, projectID, storyID int, update StoryUpdate) (Story, error) {
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return Story{}, fmt.Errorf("marshal story update: %w", err)
+	}
+	body := strings.NewReader(string(payload))
+	resp, err := c.request(ctx, "PUT", fmt.Sprintf("/projects/%d/stories/%d", projectID, storyID), body)
 	if err != nil {
 		return Story{}, err
 	}
 	return decode[Story](resp)
 }
 
-func GetProjectMemberships(projectID int) ([]Membership, error) {
-	resp, err := request("GET", fmt.Sprintf("/projects/%d/memberships", projectID), nil)
+func (c *Client) UpdateStoryState(ctx context.Context, projectID, storyID int, state string) (Story, error) {
+	if !slices.Contains(StoryStates, state) {
+		return Story{}, fmt.Errorf("invalid story state %q: must be one of %s", state, strings.Join(StoryStates, ", "))
+	}
+	return c.UpdateStory(ctx, projectID, storyID, StoryUpdate{CurrentState: &state})
+}
+
+func (c *Client) ListTasks(ctx context.Context, projectID, storyID int) ([]Task, error) {
+	resp, err := c.request(ctx, "GET", fmt.Sprintf("/projects/%d/stories/%d/tasks", projectID, storyID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return decode[[]Task](resp)
+}
+
+// CreateTask adds a task to a story. A position of 0 appends it to the end
+// of the checklist.
+func (c *Client) CreateTask(ctx context.Context, projectID, storyID int, description string, position int) (Task, error) {
+	params := map[string]any{"description": description}
+	if position > 0 {
+		params["position"] = position
+	}
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return Task{}, fmt.Errorf("marshal task: %w", err)
+	}
+	body := strings.NewReader(string(payload))
+	resp, err := c.request(ctx, "POST", fmt.Sprintf("/projects/%d/stories/%d/tasks", projectID, storyID), body)
+	if err != nil {
+		return Task{}, err
+	}
+	return decode[Task](resp)
+}
+
+func (c *Client) UpdateTask(ctx context.Context, projectID, storyID, taskID int, update TaskUpdate) (Task, error) {
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return Task{}, fmt.Errorf("marshal task update: %w", err)
+	}
+	body := strings.NewReader(string(payload))
+	resp, err := c.request(ctx, "PUT", fmt.Sprintf("/projects/%d/stories/%d/tasks/%d", projectID, storyID, taskID), body)
+	if err != nil {
+		return Task{}, err
+	}
+	return decode[Task](resp)
+}
+
+func (c *Client) DeleteTask(ctx context.Context, projectID, storyID, taskID int) error {
+	resp, err := c.request(ctx, "DELETE", fmt.Sprintf("/projects/%d/stories/%d/tasks/%d", projectID, storyID, taskID), nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *Client) ListBlockers(ctx context.Context, projectID, storyID int) ([]Blocker, error) {
+	resp, err := c.request(ctx, "GET", fmt.Sprintf("/projects/%d/stories/%d/blockers", projectID, storyID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return decode[[]Blocker](resp)
+}
+
+func (c *Client) CreateBlocker(ctx context.Context, projectID, storyID int, description string) (Blocker, error) {
+	payload, err := json.Marshal(map[string]string{"description": description})
+	if err != nil {
+		return Blocker{}, fmt.Errorf("marshal blocker: %w", err)
+	}
+	body := strings.NewReader(string(payload))
+	resp, err := c.request(ctx, "POST", fmt.Sprintf("/projects/%d/stories/%d/blockers", projectID, storyID), body)
+	if err != nil {
+		return Blocker{}, err
+	}
+	return decode[Blocker](resp)
+}
+
+func (c *Client) UpdateBlocker(ctx context.Context, projectID, storyID, blockerID int, update BlockerUpdate) (Blocker, error) {
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return Blocker{}, fmt.Errorf("marshal blocker update: %w", err)
+	}
+	body := strings.NewReader(string(payload))
+	resp, err := c.request(ctx, "PUT", fmt.Sprintf("/projects/%d/stories/%d/blockers/%d", projectID, storyID, blockerID), body)
+	if err != nil {
+		return Blocker{}, err
+	}
+	return decode[Blocker](resp)
+}
+
+func (c *Client) ListEpics(ctx context.Context, projectID int) ([]Epic, error) {
+	resp, err := c.request(ctx, "GET", fmt.Sprintf("/projects/%d/epics", projectID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return decode[[]Epic](resp)
+}
+
+func (c *Client) GetEpic(ctx context.Context, projectID, epicID int) (Epic, error) {
+	resp, err := c.request(ctx, "GET", fmt.Sprintf("/projects/%d/epics/%d", projectID, epicID), nil)
+	if err != nil {
+		return Epic{}, err
+	}
+	return decode[Epic](resp)
+}
+
+// CreateEpic creates an epic. If labelName is empty the server derives the
+// epic's label from its name.
+func (c *Client) CreateEpic(ctx context.Context, projectID int, name, description, labelName string) (Epic, error) {
+	params := map[string]any{"name": name}
+	if description != "" {
+		params["description"] = description
+	}
+	if labelName != "" {
+		params["label"] = map[string]string{"name": labelName}
+	}
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return Epic{}, fmt.Errorf("marshal epic: %w", err)
+	}
+	body := strings.NewReader(string(payload))
+	resp, err := c.request(ctx, "POST", fmt.Sprintf("/projects/%d/epics", projectID), body)
+	if err != nil {
+		return Epic{}, err
+	}
+	return decode[Epic](resp)
+}
+
+// ListIterations returns a project's iterations. An empty scope returns all
+// of them; a limit of 0 uses the server's default page size.
+func (c *Client) ListIterations(ctx context.Context, projectID int, scope string, offset, limit int) ([]Iteration, error) {
+	iterations, _, err := c.ListIterationsPage(ctx, projectID, scope, offset, limit)
+	return iterations, err
+}
+
+// ListIterationsPage is ListIterations that also reports which page of the
+// iterations was returned.
+func (c *Client) ListIterationsPage(ctx context.Context, projectID int, scope string, offset, limit int) ([]Iteration, Page, error) {
+	params := url.Values{}
+	if scope != "" {
+		if !slices.Contains(IterationScopes, scope) {
+			return nil, Page{}, fmt.Errorf("invalid iteration scope %q: must be one of %s", scope, strings.Join(IterationScopes, ", "))
+		}
+		params.Set("scope", scope)
+	}
+	if offset != 0 {
+		params.Set("offset", strconv.Itoa(offset))
+	}
+	if limit != 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	resp, err := c.request(ctx, "GET", fmt.Sprintf("/projects/%d/iterations?%s", projectID, params.Encode()), nil)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	return decodePage[Iteration](resp, offset, limit)
+}
+
+func (c *Client) GetProjectMemberships(ctx context.Context, projectID int) ([]Membership, error) {
+	resp, err := c.request(ctx, "GET", fmt.Sprintf("/projects/%d/memberships", projectID), nil)
 	if err != nil {
 		return nil, err
 	}
 	return decode[[]Membership](resp)
 }
 
-func GetProjectActivity(projectID int, occurredAfter string) ([]Activity, error) {
+// GetProjectActivityPage fetches a single page of activity that occurred
+// after occurredAfter. A limit of 0 uses a page size of 100.
+func (c *Client) GetProjectActivityPage(ctx context.Context, projectID int, occurredAfter string, offset, limit int) ([]Activity, Page, error) {
+	if limit == 0 {
+		limit = defaultPageSize
+	}
 	params := url.Values{}
 	params.Set("occurred_after", occurredAfter)
-	params.Set("limit", "100")
-	resp, err := request("GET", fmt.Sprintf("/projects/%d/activity?%s", projectID, params.Encode()), nil)
+	params.Set("limit", strconv.Itoa(limit))
+	if offset != 0 {
+		params.Set("offset", strconv.Itoa(offset))
+	}
+	resp, err := c.request(ctx, "GET", fmt.Sprintf("/projects/%d/activity?%s", projectID, params.Encode()), nil)
 	if err != nil {
-		return nil, err
+		return nil, Page{}, err
 	}
-	return decode[[]Activity](resp)
+	return decodePage[Activity](resp, offset, limit)
+}
+
+// IterProjectActivity yields every activity that occurred after
+// occurredAfter, fetching further pages as the caller ranges over them.
+func (c *Client) IterProjectActivity(ctx context.Context, projectID int, occurredAfter string) iter.Seq2[Activity, error] {
+	return paginate(0, func(offset int) ([]Activity, Page, error) {
+		return c.GetProjectActivityPage(ctx, projectID, occurredAfter, offset, defaultPageSize)
+	})
+}
+
+// GetProjectActivity returns every activity that occurred after
+// occurredAfter, across all pages.
+func (c *Client) GetProjectActivity(ctx context.Context, projectID int, occurredAfter string) ([]Activity, error) {
+	return collect(c.IterProjectActivity(ctx, projectID, occurredAfter))
 }
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors for the LiteTracker failures callers commonly need to
+// tell apart. Match them with errors.Is; an *APIError matches the sentinel
+// for its status code.
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+	// ErrSessionExpired means an imported browser session has expired and
+	// there are no credentials to log in again with.
+	ErrSessionExpired = errors.New("web session expired")
+)
+
+// APIError is a non-2xx response from LiteTracker, from either the v5 API
+// or the web session's internal /api/v1 endpoints.
+type APIError struct {
+	StatusCode int
+	// Code is LiteTracker's machine-readable error code, such as
+	// "unfound_resource", when the response body carried one.
+	Code string
+	// Message is the human-readable error from the response body, or the
+	// HTTP status text if there was none.
+	Message string
+	Body    string
+	// Retryable reports whether the same request may succeed if sent again
+	// later (429 and 502/503/504).
+	Retryable bool
+	// Resource describes what the request addressed, such as
+	// "story 456 in project 123".
+	Resource string
+
+	// session is set for web session requests, where a 401 or a "sign in"
+	// page means the login cookie has expired.
+	session bool
+}
+
+func (e *APIError) Error() string {
+	if e.Resource != "" {
+		return fmt.Sprintf("LiteTracker API %d (%s): %s", e.StatusCode, e.Resource, e.Message)
+	}
+	return fmt.Sprintf("LiteTracker API %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || (e.session && strings.Contains(e.Body, "sign in"))
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// newAPIError builds an APIError from a failed response, consuming and
+// closing its body.
+func newAPIError(resp *http.Response) *APIError {
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Body:       string(b),
+		Retryable:  isRetryableStatus(resp.StatusCode),
+		Resource:   describeResource(resp.Request),
+	}
+	var body struct {
+		Code  string `json:"code"`
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(b, &body) == nil {
+		e.Code = body.Code
+		e.Message = body.Error
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+		if e.Body != "" && len(e.Body) < 500 {
+			e.Message += " — " + e.Body
+		}
+	}
+	return e
+}
+
+// newSessionError is newAPIError for web session requests.
+func newSessionError(resp *http.Response) *APIError {
+	e := newAPIError(resp)
+	e.session = true
+	return e
+}
+
+// isSessionExpired reports whether err means the web session is no longer
+// valid and a fresh login is needed.
+func isSessionExpired(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.session && errors.Is(apiErr, ErrUnauthorized)
+}
+
+// resourceNames maps URL path collections to the singular noun used in
+// error messages.
+var resourceNames = map[string]string{
+	"projects":    "project",
+	"stories":     "story",
+	"comments":    "comment",
+	"labels":      "label",
+	"tasks":       "task",
+	"blockers":    "blocker",
+	"epics":       "epic",
+	"iterations":  "iteration",
+	"memberships": "membership",
+}
+
+// describeResource turns a request path such as
+// /services/v5/projects/123/stories/456 into "story 456 in project 123".
+func describeResource(req *http.Request) string {
+	if req == nil {
+		return ""
+	}
+	segs := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	var parts []string
+	for i := 0; i+1 < len(segs); i++ {
+		name, ok := resourceNames[segs[i]]
+		if !ok {
+			continue
+		}
+		if id := segs[i+1]; id != "" && strings.Trim(id, "0123456789") == "" {
+			parts = append(parts, name+" "+id)
+			i++
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	// Innermost resource first: "story 456 in project 123"
+	out := parts[len(parts)-1]
+	for i := len(parts) - 2; i >= 0; i-- {
+		out += " in " + parts[i]
+	}
+	return out
+}
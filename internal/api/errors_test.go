@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func errorResponse(method, url string, status int, body string) *http.Response {
+	req, _ := http.NewRequest(method, url, nil)
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Request: req}
+}
+
+func TestErrorClassification(t *testing.T) {
+	sentinels := []error{ErrUnauthorized, ErrForbidden, ErrNotFound, ErrRateLimited, ErrSessionExpired}
+	const v5 = "https://app.litetracker.com/services/v5/projects/123/stories/456"
+	const web = "https://app.litetracker.com/api/v1/stories/456/labels"
+
+	for _, tt := range []struct {
+		name      string
+		session   bool
+		url       string
+		status    int
+		body      string
+		want      error // nil for none of the sentinels
+		expired   bool
+		retryable bool
+		message   string
+	}{
+		{name: "v5 401", url: v5, status: 401, body: `{"code":"invalid_authentication","error":"Invalid authentication credentials were presented."}`,
+			want: ErrUnauthorized, message: "Invalid authentication credentials were presented."},
+		{name: "v5 403", url: v5, status: 403, want: ErrForbidden, message: "Forbidden"},
+		{name: "v5 404", url: v5, status: 404, body: `{"code":"unfound_resource","error":"The object you tried to access could not be found."}`,
+			want: ErrNotFound, message: "The object you tried to access could not be found."},
+		{name: "v5 429", url: v5, status: 429, want: ErrRateLimited, retryable: true, message: "Too Many Requests"},
+		{name: "v5 503", url: v5, status: 503, body: "upstream down", retryable: true, message: "Service Unavailable — upstream down"},
+		// Only the web session treats a sign in page as an expired login
+		{name: "v5 sign in page", url: v5, status: 500, body: "Please sign in", message: "Internal Server Error — Please sign in"},
+		{name: "web 401", session: true, url: web, status: 401, body: `{"error":"You need to sign in or sign up before continuing."}`,
+			want: ErrUnauthorized, expired: true, message: "You need to sign in or sign up before continuing."},
+		{name: "web sign in page", session: true, url: web, status: 422, body: "<html>Please sign in</html>",
+			want: ErrUnauthorized, expired: true, message: "Unprocessable Entity — <html>Please sign in</html>"},
+		{name: "web 403", session: true, url: web, status: 403, want: ErrForbidden, message: "Forbidden"},
+		{name: "web 404", session: true, url: web, status: 404, want: ErrNotFound, message: "Not Found"},
+		{name: "web 429", session: true, url: web, status: 429, want: ErrRateLimited, retryable: true, message: "Too Many Requests"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := errorResponse("GET", tt.url, tt.status, tt.body)
+			var apiErr *APIError
+			if tt.session {
+				apiErr = newSessionError(resp)
+			} else {
+				apiErr = newAPIError(resp)
+			}
+			// Callers see the error wrapped with context
+			err := fmt.Errorf("request failed: %w", apiErr)
+
+			for _, s := range sentinels {
+				if got := errors.Is(err, s); got != (s == tt.want) {
+					t.Errorf("errors.Is(err, %v) = %v", s, got)
+				}
+			}
+			if got := isSessionExpired(err); got != tt.expired {
+				t.Errorf("isSessionExpired = %v, want %v", got, tt.expired)
+			}
+			if apiErr.Retryable != tt.retryable {
+				t.Errorf("Retryable = %v, want %v", apiErr.Retryable, tt.retryable)
+			}
+			if apiErr.Message != tt.message {
+				t.Errorf("Message = %q, want %q", apiErr.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestDescribeResource(t *testing.T) {
+	for _, tt := range []struct {
+		path string
+		want string
+	}{
+		{"/services/v5/projects/123/stories/456", "story 456 in project 123"},
+		{"/services/v5/projects/123/stories/456/comments/789", "comment 789 in story 456 in project 123"},
+		{"/services/v5/projects/123/stories", "project 123"},
+		{"/services/v5/projects/123/iterations?scope=current", "project 123"},
+		{"/api/v1/stories/456/labels/12", "label 12 in story 456"},
+		{"/services/v5/me", ""},
+		{"/services/v5/projects/abc", ""},
+	} {
+		req, _ := http.NewRequest("GET", "https://app.litetracker.com"+tt.path, nil)
+		if got := describeResource(req); got != tt.want {
+			t.Errorf("describeResource(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	if got := describeResource(nil); got != "" {
+		t.Errorf("describeResource(nil) = %q, want empty", got)
+	}
+}
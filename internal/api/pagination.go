@@ -0,0 +1,97 @@
+package api
+
+import (
+	"iter"
+	"net/http"
+	"strconv"
+)
+
+// defaultPageSize is the page size used when walking every page of a list
+// endpoint and the caller has not picked one.
+const defaultPageSize = 100
+
+// Page describes one page of a paginated list response, as reported by
+// LiteTracker's X-Tracker-Pagination-* headers.
+type Page struct {
+	Offset   int
+	Limit    int
+	Returned int
+	// Total is the number of items across all pages, or -1 if the server
+	// did not report it.
+	Total int
+}
+
+// HasMore reports whether there are items after this page.
+func (p Page) HasMore() bool {
+	if p.Total >= 0 {
+		return p.Offset+p.Returned < p.Total
+	}
+	// Without a total, a full page means there may be more.
+	return p.Limit > 0 && p.Returned >= p.Limit
+}
+
+func parsePage(h http.Header, offset, limit, returned int) Page {
+	p := Page{Offset: offset, Limit: limit, Returned: returned, Total: -1}
+	if v, err := strconv.Atoi(h.Get("X-Tracker-Pagination-Offset")); err == nil {
+		p.Offset = v
+	}
+	if v, err := strconv.Atoi(h.Get("X-Tracker-Pagination-Limit")); err == nil {
+		p.Limit = v
+	}
+	if v, err := strconv.Atoi(h.Get("X-Tracker-Pagination-Returned")); err == nil {
+		p.Returned = v
+	}
+	if v, err := strconv.Atoi(h.Get("X-Tracker-Pagination-Total")); err == nil {
+		p.Total = v
+	}
+	return p
+}
+
+// decodePage decodes a list response along with its pagination headers.
+// offset and limit are what was requested, used when the headers are absent.
+func decodePage[T any](resp *http.Response, offset, limit int) ([]T, Page, error) {
+	h := resp.Header
+	items, err := decode[[]T](resp)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	return items, parsePage(h, offset, limit, len(items)), nil
+}
+
+// paginate walks every page starting at offset, fetching the next page only
+// once the caller has consumed the previous one. Stopping the range loop
+// stops the fetching. An error is yielded once and ends the sequence.
+func paginate[T any](offset int, fetch func(offset int) ([]T, Page, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			items, page, err := fetch(offset)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if len(items) == 0 || !page.HasMore() {
+				return
+			}
+			offset = page.Offset + len(items)
+		}
+	}
+}
+
+// collect drains a paginated sequence into a slice, stopping at the first
+// error.
+func collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var out []T
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// requestTimeout bounds each request sent to LiteTracker, from when it
+// leaves the rate limiter until its response body is closed.
+const requestTimeout = 30 * time.Second
+
+// tokenBucket is a client-side rate limiter shared by every request a
+// Client makes, whether it goes through the v5 API or the web session.
+// Interactive requests (MCP tool calls) take priority: while one is waiting,
+// background requests (sync, daemon polling) hold off, and background
+// requests never take the last token in the bucket.
+type tokenBucket struct {
+	mu          sync.Mutex
+	rate        float64 // tokens added per second
+	burst       float64
+	tokens      float64
+	last        time.Time
+	interactive int // interactive requests currently waiting
+	now         func() time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now(), now: time.Now}
+}
+
+// reserve returns how long to wait before trying again, or 0 if a token was
+// taken.
+func (b *tokenBucket) reserve(background bool) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	need := 1.0
+	if background {
+		if b.interactive > 0 {
+			return time.Duration(float64(time.Second) / b.rate)
+		}
+		if b.burst > 1 {
+			// Leave one token for interactive requests
+			need = 2
+		}
+	}
+	if b.tokens >= need {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((need - b.tokens) / b.rate * float64(time.Second))
+}
+
+// wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context, background bool) error {
+	if !background {
+		b.mu.Lock()
+		b.interactive++
+		b.mu.Unlock()
+		defer func() {
+			b.mu.Lock()
+			b.interactive--
+			b.mu.Unlock()
+		}()
+	}
+	for {
+		d := b.reserve(background)
+		if d == 0 {
+			return nil
+		}
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+type backgroundKey struct{}
+
+// WithBackgroundPriority marks ctx as background traffic, such as syncing or
+// polling, which yields to interactive MCP tool calls under rate limiting.
+func WithBackgroundPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, backgroundKey{}, true)
+}
+
+func isBackground(ctx context.Context) bool {
+	v, _ := ctx.Value(backgroundKey{}).(bool)
+	return v
+}
+
+// rateLimitedTransport makes every request wait for the limiter before it
+// is sent, then gives it timeout to complete. The wait doesn't count
+// against the timeout, so background requests queued behind interactive
+// ones don't time out before they are sent. A nil limiter disables rate
+// limiting.
+type rateLimitedTransport struct {
+	base    http.RoundTripper
+	limiter *tokenBucket
+	timeout time.Duration
+}
+
+func (t rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.limiter != nil {
+		if err := t.limiter.wait(req.Context(), isBackground(req.Context())); err != nil {
+			return nil, err
+		}
+	}
+	if t.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{resp.Body, cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's timeout once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
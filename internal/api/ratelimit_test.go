@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestBucket returns a bucket whose clock only moves when the returned
+// advance func is called.
+func newTestBucket(rate float64, burst int) (*tokenBucket, func(time.Duration)) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newTokenBucket(rate, burst)
+	b.last = now
+	b.now = func() time.Time { return now }
+	return b, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestTokenBucketBurst(t *testing.T) {
+	b, _ := newTestBucket(1, 3)
+	for i := range 3 {
+		if d := b.reserve(false); d != 0 {
+			t.Fatalf("request %d waited %v within the burst", i+1, d)
+		}
+	}
+	if d := b.reserve(false); d != time.Second {
+		t.Errorf("request after the burst waits %v, want 1s", d)
+	}
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	b, advance := newTestBucket(2, 2)
+	b.reserve(false)
+	b.reserve(false)
+
+	advance(250 * time.Millisecond)
+	if d := b.reserve(false); d != 250*time.Millisecond {
+		t.Errorf("half a token in: wait = %v, want 250ms", d)
+	}
+	advance(250 * time.Millisecond)
+	if d := b.reserve(false); d != 0 {
+		t.Errorf("after refilling a token: wait = %v, want 0", d)
+	}
+	// The bucket never holds more than burst
+	advance(time.Hour)
+	for range 2 {
+		b.reserve(false)
+	}
+	if d := b.reserve(false); d == 0 {
+		t.Error("bucket refilled past its burst")
+	}
+}
+
+func TestTokenBucketBackgroundYields(t *testing.T) {
+	b, _ := newTestBucket(1, 2)
+
+	// Background requests leave the last token for interactive ones
+	if d := b.reserve(true); d != 0 {
+		t.Fatalf("first background request waited %v", d)
+	}
+	if d := b.reserve(true); d == 0 {
+		t.Error("background request took the last token")
+	}
+	if d := b.reserve(false); d != 0 {
+		t.Errorf("interactive request waited %v for the last token", d)
+	}
+
+	// While an interactive request waits, background ones hold off even
+	// when the bucket is full
+	b, _ = newTestBucket(1, 5)
+	b.interactive = 1
+	if d := b.reserve(true); d == 0 {
+		t.Error("background request went ahead of a waiting interactive request")
+	}
+	b.interactive = 0
+	if d := b.reserve(true); d != 0 {
+		t.Errorf("background request waited %v with no interactive request waiting", d)
+	}
+}
+
+func TestTokenBucketWaitCancelled(t *testing.T) {
+	b, _ := newTestBucket(0.001, 1)
+	b.reserve(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.wait(ctx, false) }()
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("wait = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("wait did not return after its context was cancelled")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.interactive != 0 {
+		t.Errorf("interactive = %d after the wait ended, want 0", b.interactive)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	c := New(Options{Token: "t"})
+	if tr := c.http.Transport.(rateLimitedTransport); tr.limiter != nil {
+		t.Error("rate limiter set up with RateLimitRPS 0")
+	}
+	if tr := c.web.client.Transport.(rateLimitedTransport); tr.limiter != nil {
+		t.Error("web session rate limiter set up with RateLimitRPS 0")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRateLimitWaitOutsideTimeout(t *testing.T) {
+	b := newTokenBucket(10, 1)
+	b.reserve(false)
+	tr := rateLimitedTransport{
+		base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if err := req.Context().Err(); err != nil {
+				return nil, err
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+		}),
+		limiter: b,
+		timeout: 20 * time.Millisecond,
+	}
+	// The limiter holds this request back for about 100ms, longer than
+	// its timeout
+	req, _ := http.NewRequest(http.MethodGet, "http://litetracker.test/", nil)
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("request timed out while waiting for the limiter: %v", err)
+	}
+	resp.Body.Close()
+}
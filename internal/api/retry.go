@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how request retries transient failures: network
+// errors, 429 Too Many Requests and 502/503/504 responses.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt. 0
+	// disables retrying.
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry. It doubles on each
+	// further retry, with jitter, up to MaxDelay.
+	BaseDelay time.Duration
+	// MaxDelay caps both the computed backoff and a server's Retry-After.
+	MaxDelay time.Duration
+}
+
+// backoff returns the delay before retry number attempt (0-based), using
+// the server's Retry-After header when it sent one. Negative delays in the
+// policy count as zero.
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	maxDelay := max(p.MaxDelay, 0)
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(d, maxDelay)
+		}
+	}
+	d := max(p.BaseDelay, 0) << attempt
+	if d <= 0 || d > maxDelay {
+		d = maxDelay
+	}
+	if d == 0 {
+		return 0
+	}
+	// Equal jitter: wait at least half the backoff so retries still spread
+	// out, plus a random share of the rest so clients don't retry in step.
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+type retryUnsafeKey struct{}
+
+// WithRetryNonIdempotent marks ctx so that POST requests made with it are
+// retried like idempotent ones. Only use it where sending the same create
+// twice is harmless or the caller deduplicates.
+func WithRetryNonIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryUnsafeKey{}, true)
+}
+
+func retryNonIdempotent(ctx context.Context) bool {
+	v, _ := ctx.Value(retryUnsafeKey{}).(bool)
+	return v
+}
+
+// doWithRetry sends the request built by newReq, retrying transient
+// failures according to the client's RetryPolicy. newReq is called once
+// per attempt so the body can be replayed.
+func (c *Client) doWithRetry(ctx context.Context, hc *http.Client, method string, newReq func() (*http.Request, error)) (*http.Response, error) {
+	policy := c.opts.Retry
+	retryable := isIdempotent(method) || retryNonIdempotent(ctx)
+
+	for attempt := 0; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := hc.Do(req)
+
+		transient := (err != nil && ctx.Err() == nil) || (err == nil && isRetryableStatus(resp.StatusCode))
+		if !transient || !retryable || attempt >= policy.MaxRetries {
+			if attempt > 0 {
+				slog.Info("LiteTracker request finished after retries",
+					"method", method, "url", req.URL.Path, "retries", attempt, "ok", err == nil && resp.StatusCode < 400)
+			}
+			return resp, err
+		}
+
+		delay := policy.backoff(attempt, resp)
+		attrs := []any{"method", method, "url", req.URL.Path, "attempt", attempt + 1, "max_retries", policy.MaxRetries, "delay", delay}
+		if err != nil {
+			attrs = append(attrs, "err", err)
+		} else {
+			attrs = append(attrs, "status", resp.StatusCode)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		slog.Warn("retrying LiteTracker request", attrs...)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(ctx.Err(), err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoffNegativeDelays(t *testing.T) {
+	for _, p := range []RetryPolicy{
+		{MaxRetries: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: -time.Second},
+		{MaxRetries: 3, BaseDelay: -time.Second, MaxDelay: -time.Second},
+		{MaxRetries: 3},
+	} {
+		resp := &http.Response{Header: http.Header{"Retry-After": {"5"}}}
+		for attempt := range p.MaxRetries {
+			if d := p.backoff(attempt, nil); d != 0 {
+				t.Errorf("%+v: backoff(%d) = %v, want 0", p, attempt, d)
+			}
+			if d := p.backoff(attempt, resp); d != 0 {
+				t.Errorf("%+v: backoff(%d) with Retry-After = %v, want 0", p, attempt, d)
+			}
+		}
+	}
+
+	p := RetryPolicy{MaxRetries: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	if d := p.backoff(2, nil); d < 200*time.Millisecond || d > 400*time.Millisecond {
+		t.Errorf("backoff(2) = %v, want between 200ms and 400ms", d)
+	}
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// savedSession is the web session written to Options.SessionFile so a new
+// process can reuse it instead of logging in again.
+type savedSession struct {
+	Email  string `json:"email"`
+	WebURL string `json:"web_url"`
+	// Imported is set for sessions copied from a browser, which cannot be
+	// renewed without the user importing them again.
+	Imported bool          `json:"imported,omitempty"`
+	Cookies  []savedCookie `json:"cookies"`
+}
+
+type savedCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// restoreSession loads the saved session cookies into the jar. It reports
+// false if there is no saved session for this account. The caller checks
+// the session is still valid before relying on it.
+func (wc *WebClient) restoreSession() bool {
+	path := wc.api.opts.SessionFile
+	if path == "" {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("could not read saved web session", "path", path, "err", err)
+		}
+		return false
+	}
+	var s savedSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		slog.Warn("ignoring corrupt saved web session", "path", path, "err", err)
+		return false
+	}
+	if s.Email != wc.api.opts.Email || s.WebURL != wc.api.opts.WebURL || len(s.Cookies) == 0 {
+		return false
+	}
+	u, err := url.Parse(wc.api.opts.WebURL)
+	if err != nil {
+		return false
+	}
+	cookies := make([]*http.Cookie, len(s.Cookies))
+	for i, c := range s.Cookies {
+		cookies[i] = &http.Cookie{Name: c.Name, Value: c.Value, Path: "/"}
+	}
+	wc.client.Jar.SetCookies(u, cookies)
+	wc.imported = s.Imported
+	return true
+}
+
+// discardSession forgets an expired saved session: its cookies are expired
+// in the jar and the session file removed, so the next login starts clean.
+// The jar itself is kept, as requests in flight may be using it.
+func (wc *WebClient) discardSession() {
+	if u, err := url.Parse(wc.api.opts.WebURL); err == nil {
+		var expired []*http.Cookie
+		for _, c := range wc.client.Jar.Cookies(u) {
+			expired = append(expired, &http.Cookie{Name: c.Name, Path: "/", MaxAge: -1})
+		}
+		wc.client.Jar.SetCookies(u, expired)
+	}
+	if path := wc.api.opts.SessionFile; path != "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			slog.Warn("could not remove expired web session", "path", path, "err", err)
+		}
+	}
+}
+
+// saveSession writes the jar's cookies for the web app to
+// Options.SessionFile, readable only by the current user.
+func (wc *WebClient) saveSession() {
+	path := wc.api.opts.SessionFile
+	if path == "" {
+		return
+	}
+	if err := wc.writeSession(path); err != nil {
+		slog.Warn("could not save web session", "path", path, "err", err)
+	}
+}
+
+func (wc *WebClient) writeSession(path string) error {
+	u, err := url.Parse(wc.api.opts.WebURL)
+	if err != nil {
+		return err
+	}
+	s := savedSession{Email: wc.api.opts.Email, WebURL: wc.api.opts.WebURL, Imported: wc.imported}
+	for _, c := range wc.client.Jar.Cookies(u) {
+		s.Cookies = append(s.Cookies, savedCookie{Name: c.Name, Value: c.Value})
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	// Write to a temp file and rename so a crash never leaves a torn file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".web-session-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace session file: %w", err)
+	}
+	return nil
+}
+
+// ImportWebSession signs the web session in with cookies copied from a
+// browser, for accounts that log in through SSO. The cookies are checked
+// against LiteTracker and then saved to Options.SessionFile for later
+// processes to reuse. Once they expire, write tools fail with
+// ErrSessionExpired unless a password is also configured.
+func (c *Client) ImportWebSession(ctx context.Context, cookies []*http.Cookie) error {
+	wc := c.web
+	if c.opts.SessionFile == "" {
+		return fmt.Errorf("no session file configured to save the imported session to")
+	}
+	u, err := url.Parse(c.opts.WebURL)
+	if err != nil {
+		return fmt.Errorf("parse web URL: %w", err)
+	}
+
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	for _, ck := range cookies {
+		ck.Path, ck.Domain = "/", ""
+	}
+	wc.client.Jar.SetCookies(u, cookies)
+
+	ok, err := wc.checkSession(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("LiteTracker did not accept the imported cookies as a signed-in session; copy them again from a browser tab where you are logged in: %w", ErrUnauthorized)
+	}
+	wc.loggedIn, wc.restoreTried, wc.imported = true, true, true
+	return wc.writeSession(c.opts.SessionFile)
+}
+
+// checkSession reports whether the jar holds a signed-in session. The login
+// page redirects signed-in users away, so landing anywhere else means the
+// cookies are good.
+func (wc *WebClient) checkSession(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", wc.api.opts.WebURL+"/login", nil)
+	if err != nil {
+		return false, fmt.Errorf("build login page request: %w", err)
+	}
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("fetch login page: %w", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return false, fmt.Errorf("check session: %w", newSessionError(resp))
+	}
+	return resp.Request.URL.Path != "/login", nil
+}
+
+// ParseCookies reads browser cookies for the web app at webURL, either as a
+// Cookie header value ("a=1; b=2", optionally with the "Cookie:" prefix) or
+// as a Netscape cookies.txt export, from which only cookies for webURL's
+// host are taken.
+func ParseCookies(data, webURL string) ([]*http.Cookie, error) {
+	u, err := url.Parse(webURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse web URL: %w", err)
+	}
+	host := u.Hostname()
+
+	var cookies []*http.Cookie
+	var header []string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		// cookies.txt marks HttpOnly cookies with a prefix that looks like a comment
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if fields := strings.Split(line, "\t"); len(fields) == 7 {
+			domain := strings.TrimPrefix(fields[0], ".")
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				cookies = append(cookies, &http.Cookie{Name: fields[5], Value: fields[6]})
+			}
+			continue
+		}
+		header = append(header, strings.TrimSpace(strings.TrimPrefix(line, "Cookie:")))
+	}
+	if len(header) > 0 {
+		parsed, err := http.ParseCookie(strings.Join(header, "; "))
+		if err != nil {
+			return nil, fmt.Errorf("parse cookie header: %w", err)
+		}
+		cookies = append(cookies, parsed...)
+	}
+	if len(cookies) == 0 {
+		return nil, fmt.Errorf("no cookies for %s found", host)
+	}
+	return cookies, nil
+}
@@ -0,0 +1,273 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+	"github.com/MelianLabs/litetracker-mcp/internal/fake"
+)
+
+func TestWebSessionPersistsAcrossClients(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	pid := srv.AddProject("Web")
+	st := srv.AddStory(pid, api.Story{Title: "Checkout"})
+	ctx := context.Background()
+
+	opts := srv.Options()
+	opts.SessionFile = filepath.Join(t.TempDir(), "web-session.json")
+	post := func(text string) {
+		t.Helper()
+		// A new Client per post, like a new serve process
+		if _, err := api.New(opts).WebPostComment(ctx, pid, st.ID, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	post("first")
+	info, err := os.Stat(opts.SessionFile)
+	if err != nil {
+		t.Fatalf("session not saved: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("session file mode = %v, want 0600", perm)
+	}
+
+	post("second")
+	if srv.Logins() != 1 {
+		t.Errorf("logins after restoring session = %d, want 1", srv.Logins())
+	}
+
+	// An expired saved session falls back to a password login
+	srv.ExpireSessions()
+	post("third")
+	if srv.Logins() != 2 {
+		t.Errorf("logins after session expired = %d, want 2", srv.Logins())
+	}
+	if n := len(srv.Comments(pid, st.ID)); n != 3 {
+		t.Errorf("comments = %d, want 3", n)
+	}
+
+	// A session saved for another account is not reused
+	opts.Email = "someone-else@example.com"
+	if _, err := api.New(opts).WebPostComment(ctx, pid, st.ID, "fourth"); err == nil {
+		t.Error("posted with another account's saved session")
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestExpiredSavedSessionChecked(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	pid := srv.AddProject("Web")
+	st := srv.AddStory(pid, api.Story{Title: "Checkout"})
+	ctx := context.Background()
+
+	opts := srv.Options()
+	opts.SessionFile = filepath.Join(t.TempDir(), "web-session.json")
+	if _, err := api.New(opts).WebPostComment(ctx, pid, st.ID, "first"); err != nil {
+		t.Fatal(err)
+	}
+	srv.ExpireSessions()
+
+	// The restored session is found stale up front, so the write is sent
+	// once, after a fresh login, rather than failing first
+	var writes int
+	var loginCookies string
+	opts.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method == "POST" && !strings.HasSuffix(req.URL.Path, "/login") {
+			writes++
+		}
+		if req.Method == "GET" && req.URL.Path == "/login" {
+			loginCookies = req.Header.Get("Cookie")
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	if _, err := api.New(opts).WebPostComment(ctx, pid, st.ID, "second"); err != nil {
+		t.Fatal(err)
+	}
+	if writes != 1 {
+		t.Errorf("comment requests = %d, want 1", writes)
+	}
+	if loginCookies != "" {
+		t.Errorf("fresh login sent the expired cookies %q", loginCookies)
+	}
+	if srv.Logins() != 2 {
+		t.Errorf("logins = %d, want 2", srv.Logins())
+	}
+
+	// Without a password the stale session is reported, and forgotten
+	srv.ExpireSessions()
+	opts.Password = ""
+	if _, err := api.New(opts).WebPostComment(ctx, pid, st.ID, "third"); err == nil {
+		t.Fatal("posted with an expired session")
+	}
+	if _, err := os.Stat(opts.SessionFile); !os.IsNotExist(err) {
+		t.Errorf("expired session file still present: %v", err)
+	}
+}
+
+func TestTOTPLogin(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	pid := srv.AddProject("Web")
+	st := srv.AddStory(pid, api.Story{Title: "Checkout"})
+	srv.EnableTOTP("JBSWY3DPEHPK3PXP")
+	ctx := context.Background()
+
+	if _, err := api.New(srv.Options()).WebPostComment(ctx, pid, st.ID, "with 2FA"); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Logins() != 1 {
+		t.Errorf("logins = %d, want 1", srv.Logins())
+	}
+
+	opts := srv.Options()
+	opts.TOTPSecret = ""
+	if _, err := api.New(opts).WebPostComment(ctx, pid, st.ID, "without"); !errors.Is(err, api.ErrUnauthorized) {
+		t.Errorf("login without TOTP secret: err = %v, want ErrUnauthorized", err)
+	}
+	opts.TOTPSecret = "GEZDGNBVGY3TQOJQ"
+	if _, err := api.New(opts).WebPostComment(ctx, pid, st.ID, "wrong"); !errors.Is(err, api.ErrUnauthorized) {
+		t.Errorf("login with wrong TOTP secret: err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestImportWebSession(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	pid := srv.AddProject("Web")
+	st := srv.AddStory(pid, api.Story{Title: "Checkout"})
+	ctx := context.Background()
+
+	// An SSO user has no password to log in with
+	opts := srv.Options()
+	opts.Email, opts.Password = "", ""
+	opts.SessionFile = filepath.Join(t.TempDir(), "web-session.json")
+
+	bogus := []*http.Cookie{{Name: "_litetracker_session", Value: "bogus"}}
+	if err := api.New(opts).ImportWebSession(ctx, bogus); !errors.Is(err, api.ErrUnauthorized) {
+		t.Errorf("import of signed-out cookies: err = %v, want ErrUnauthorized", err)
+	}
+
+	c := srv.BrowserSession()
+	cookies, err := api.ParseCookies("Cookie: theme=dark; "+c.String(), opts.WebURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := api.New(opts).ImportWebSession(ctx, cookies); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later process picks the imported session up from the session file
+	if _, err := api.New(opts).WebPostComment(ctx, pid, st.ID, "from SSO"); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Logins() != 0 {
+		t.Errorf("logins = %d, want 0", srv.Logins())
+	}
+
+	srv.ExpireSessions()
+	_, err = api.New(opts).WebPostComment(ctx, pid, st.ID, "expired")
+	if !errors.Is(err, api.ErrSessionExpired) {
+		t.Fatalf("post with expired import: err = %v, want ErrSessionExpired", err)
+	}
+	if !strings.Contains(err.Error(), "litetracker login") {
+		t.Errorf("error %q does not say how to fix it", err)
+	}
+}
+
+func TestParseCookiesFile(t *testing.T) {
+	txt := strings.Join([]string{
+		"# Netscape HTTP Cookie File",
+		"#HttpOnly_.litetracker.com\tTRUE\t/\tTRUE\t0\t_litetracker_session\tabc",
+		"app.litetracker.com\tFALSE\t/\tTRUE\t0\tremember_user_token\tdef",
+		".example.com\tTRUE\t/\tFALSE\t0\tother\tghi",
+	}, "\n")
+	cookies, err := api.ParseCookies(txt, "https://app.litetracker.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range cookies {
+		got = append(got, c.String())
+	}
+	want := []string{"_litetracker_session=abc", "remember_user_token=def"}
+	if !slices.Equal(got, want) {
+		t.Errorf("cookies = %v, want %v", got, want)
+	}
+}
+
+func TestBulkUpdateHoldsWebSession(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	pid := srv.AddProject("Web")
+	first := srv.AddStory(pid, api.Story{Title: "Checkout"})
+	second := srv.AddStory(pid, api.Story{Title: "Search"})
+	other := srv.AddStory(pid, api.Story{Title: "Login"})
+	ctx := context.Background()
+
+	// Hold the bulk update's first label request until released
+	started, release := make(chan struct{}), make(chan struct{})
+	var mu sync.Mutex
+	var labelled []string
+	opts := srv.Options()
+	opts.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method == "POST" && strings.HasSuffix(req.URL.Path, "/labels") {
+			mu.Lock()
+			labelled = append(labelled, req.URL.Path)
+			mu.Unlock()
+			if req.URL.Path == fmt.Sprintf("/api/v1/stories/%d/labels", first.ID) {
+				close(started)
+				<-release
+			}
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	c := api.New(opts)
+
+	bulk := make(chan error, 1)
+	go func() {
+		_, err := c.BulkUpdateStories(ctx, pid, []int{first.ID, second.ID}, api.BulkOperation{Kind: "add_label", Label: "backend"})
+		bulk <- err
+	}()
+	<-started
+	single := make(chan error, 1)
+	go func() {
+		_, err := c.WebAddLabel(ctx, pid, other.ID, "backend")
+		single <- err
+	}()
+	select {
+	case err := <-single:
+		t.Fatalf("single-story call ran during the bulk update (err %v)", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if err := <-bulk; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-single; err != nil {
+		t.Fatal(err)
+	}
+
+	if want := fmt.Sprintf("/api/v1/stories/%d/labels", other.ID); len(labelled) != 3 || labelled[2] != want {
+		t.Errorf("label requests = %v, want %s last", labelled, want)
+	}
+	if srv.Logins() != 1 {
+		t.Errorf("logins = %d, want 1", srv.Logins())
+	}
+}
@@ -0,0 +1,36 @@
+package api_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+)
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 appendix B SHA1 vectors, truncated to 6 digits. The secret is
+	// base32 for "12345678901234567890".
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := api.TOTP(secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTP at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	if _, err := api.TOTP("not base32!", time.Now()); err == nil {
+		t.Error("invalid secret accepted")
+	}
+}
@@ -1,5 +1,11 @@
 package api
 
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+)
+
 type Project struct {
 	ID          int    `json:"id"`
 	Title       string `json:"title"`
@@ -41,10 +47,40 @@ type Story struct {
 	RequestedByID *int         `json:"requested_by_id,omitempty"`
 	CreatedAt     string       `json:"created_at"`
 	UpdatedAt     string       `json:"updated_at"`
+	AcceptedAt    string       `json:"accepted_at,omitempty"`
 	URL           string       `json:"url"`
 	ProjectID     *int         `json:"project_id,omitempty"`
 }
 
+type Epic struct {
+	ID          int    `json:"id"`
+	ProjectID   int    `json:"project_id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Label       Label  `json:"label"`
+	URL         string `json:"url"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+type Iteration struct {
+	Number       int     `json:"number"`
+	ProjectID    int     `json:"project_id"`
+	Kind         string  `json:"kind,omitempty"`
+	Length       int     `json:"length"`
+	TeamStrength float64 `json:"team_strength"`
+	Start        string  `json:"start"`
+	Finish       string  `json:"finish"`
+	Velocity     float64 `json:"velocity"`
+	Stories      []Story `json:"stories"`
+}
+
+// StoryPanels lists the panels a story can be moved to the top or bottom of.
+var StoryPanels = []string{"current", "backlog", "icebox"}
+
+// IterationScopes lists the scopes accepted by ListIterations.
+var IterationScopes = []string{"current", "backlog", "current_backlog", "done"}
+
 type Comment struct {
 	ID        int     `json:"id"`
 	Text      string  `json:"text"`
@@ -54,6 +90,54 @@ type Comment struct {
 	UpdatedAt string  `json:"updated_at"`
 }
 
+type Task struct {
+	ID          int    `json:"id"`
+	StoryID     int    `json:"story_id"`
+	Description string `json:"description"`
+	Complete    bool   `json:"complete"`
+	Position    int    `json:"position"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// TaskUpdate holds the task fields to change. Nil fields are left out of
+// the request so the server keeps their current values.
+type TaskUpdate struct {
+	Description *string `json:"description,omitempty"`
+	Complete    *bool   `json:"complete,omitempty"`
+	Position    *int    `json:"position,omitempty"`
+}
+
+type Blocker struct {
+	ID          int    `json:"id"`
+	StoryID     int    `json:"story_id"`
+	Description string `json:"description"`
+	Resolved    bool   `json:"resolved"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+var storyRefRegex = regexp.MustCompile(`#(\d+)`)
+
+// BlockingStoryIDs returns the story IDs referenced as "#123" in the
+// blocker's description.
+func (b Blocker) BlockingStoryIDs() []int {
+	var ids []int
+	for _, m := range storyRefRegex.FindAllStringSubmatch(b.Description, -1) {
+		if id, err := strconv.Atoi(m[1]); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// BlockerUpdate holds the blocker fields to change. Nil fields are left out
+// of the request so the server keeps their current values.
+type BlockerUpdate struct {
+	Description *string `json:"description,omitempty"`
+	Resolved    *bool   `json:"resolved,omitempty"`
+}
+
 type ActivityChange struct {
 	Kind       string         `json:"kind"`
 	ID         int            `json:"id"`
@@ -100,6 +184,55 @@ type Me struct {
 	Projects []ProjectMembership `json:"projects"`
 }
 
+// StoryUpdate holds the story fields to change. Nil fields are left out of
+// the request so the server keeps their current values.
+type StoryUpdate struct {
+	Title         *string `json:"name,omitempty"`
+	Description   *string `json:"description,omitempty"`
+	StoryType     *string `json:"story_type,omitempty"`
+	CurrentState  *string `json:"current_state,omitempty"`
+	Estimate      *int    `json:"estimate,omitempty"`
+	StoryPriority *string `json:"story_priority,omitempty"`
+	BeforeID      *int    `json:"before_id,omitempty"`
+	AfterID       *int    `json:"after_id,omitempty"`
+	// ClearEstimate removes the story's estimate by sending an explicit
+	// null. Estimate is ignored when it is set.
+	ClearEstimate bool `json:"-"`
+}
+
+func (u StoryUpdate) MarshalJSON() ([]byte, error) {
+	type plain StoryUpdate
+	if !u.ClearEstimate {
+		return json.Marshal(plain(u))
+	}
+	return json.Marshal(struct {
+		plain
+		Estimate *int `json:"estimate"`
+	}{plain: plain(u)})
+}
+
+func (u *StoryUpdate) UnmarshalJSON(data []byte) error {
+	type plain StoryUpdate
+	var v struct {
+		plain
+		Estimate json.RawMessage `json:"estimate"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*u = StoryUpdate(v.plain)
+	switch {
+	case string(v.Estimate) == "null":
+		u.ClearEstimate = true
+	case v.Estimate != nil:
+		return json.Unmarshal(v.Estimate, &u.Estimate)
+	}
+	return nil
+}
+
+// StoryStates lists the workflow states a story can be moved into.
+var StoryStates = []string{"started", "unstarted", "delivered", "accepted", "rejected"}
+
 type ListStoriesOpts struct {
 	Filter      string
 	Query       int
@@ -107,7 +240,12 @@ type ListStoriesOpts struct {
 	SectionType string
 	OwnedBy     int
 	State       string
-	Limit       int
+	// Limit is the page size, not a cap on the results: ListStories and
+	// IterStories keep fetching pages until every match is returned. To get
+	// at most N stories, call ListStoriesPage with Limit N or stop ranging
+	// over IterStories early.
+	Limit  int
+	Offset int
 }
 
 type Membership struct {
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestBlockingStoryIDs(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		want        []int
+	}{
+		{"Blocked by #123", []int{123}},
+		{"Needs #12 and #345 first", []int{12, 345}},
+		{"#7", []int{7}},
+		{"Waiting on design", nil},
+		{"Issue # 12 and #abc", nil},
+		{"See #99999999999999999999999", nil},
+	} {
+		if got := (Blocker{Description: tt.description}).BlockingStoryIDs(); !slices.Equal(got, tt.want) {
+			t.Errorf("BlockingStoryIDs(%q) = %v, want %v", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestStoryUpdateEstimateJSON(t *testing.T) {
+	est := 3
+	for _, tt := range []struct {
+		update StoryUpdate
+		json   string
+	}{
+		{StoryUpdate{Estimate: &est}, `{"estimate":3}`},
+		{StoryUpdate{ClearEstimate: true}, `{"estimate":null}`},
+		{StoryUpdate{Estimate: &est, ClearEstimate: true}, `{"estimate":null}`},
+		{StoryUpdate{}, `{}`},
+	} {
+		data, err := json.Marshal(tt.update)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.json {
+			t.Errorf("Marshal(%+v) = %s, want %s", tt.update, data, tt.json)
+		}
+		var back StoryUpdate
+		if err := json.Unmarshal(data, &back); err != nil {
+			t.Fatal(err)
+		}
+		if back.ClearEstimate != tt.update.ClearEstimate || (back.Estimate == nil) != (tt.update.Estimate == nil || tt.update.ClearEstimate) {
+			t.Errorf("Unmarshal(%s) = %+v", data, back)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,47 +14,72 @@ import (
 	"strings"
 	"sync"
 	"time"
-
-	"github.com/MelianLabs/litetracker-mcp/internal/config"
-)
-
-var (
-	webOnce   sync.Once
-	webClient *WebClient
 )
 
+// WebClient is a Client's cookie-authenticated session with the LiteTracker
+// web app, used for the writes the v5 API does not support.
 type WebClient struct {
-	mu       sync.Mutex
+	// mu is held for writing by each web session call, and for reading by
+	// the workers of a bulk update, which share one login.
+	mu       sync.RWMutex
 	client   *http.Client
 	loggedIn bool
+	// restoreTried is set once the saved session has been considered, so
+	// an expired one falls through to a password login.
+	restoreTried bool
+	// imported is set while the session in use came from a browser via
+	// ImportWebSession rather than a password login.
+	imported bool
+	api      *Client
 }
 
-func getWebClient() *WebClient {
-	webOnce.Do(func() {
-		jar, _ := cookiejar.New(nil)
-		webClient = &WebClient{
-			client: &http.Client{
-				Timeout: 30 * time.Second,
-				Jar:     jar,
-			},
-		}
-	})
-	return webClient
+func newWebClient(c *Client, transport http.RoundTripper) *WebClient {
+	jar, _ := cookiejar.New(nil)
+	return &WebClient{
+		client: &http.Client{
+			Jar:       jar,
+			Transport: transport,
+		},
+		api: c,
+	}
 }
 
 var csrfRegex = regexp.MustCompile(`csrf-token[^>]*content="([^"]*)"`)
 
-func (wc *WebClient) ensureLoggedIn() error {
+func (wc *WebClient) ensureLoggedIn(ctx context.Context) error {
 	if wc.loggedIn {
 		return nil
 	}
-	if config.C.Email == "" || config.C.Password == "" {
+	if !wc.restoreTried {
+		wc.restoreTried = true
+		if wc.restoreSession() {
+			ok, err := wc.checkSession(ctx)
+			if err != nil {
+				// Try the saved session again next time
+				wc.restoreTried = false
+				return err
+			}
+			if ok {
+				wc.loggedIn = true
+				return nil
+			}
+			wc.discardSession()
+		}
+	}
+	if wc.api.opts.Email == "" || wc.api.opts.Password == "" {
+		if wc.imported {
+			return fmt.Errorf("%w: the browser session imported with `litetracker login` is no longer valid; run `litetracker login` again, or set LITETRACKER_EMAIL and LITETRACKER_PASSWORD", ErrSessionExpired)
+		}
 		return fmt.Errorf("LITETRACKER_EMAIL and LITETRACKER_PASSWORD must be set in ~/litetracker-go/.env for posting comments (LiteTracker API does not support comment creation)")
 	}
 
 	// GET /login to get CSRF token and session cookie
-	loginURL := config.C.WebURL + "/login"
-	resp, err := wc.client.Get(loginURL)
+	loginURL := wc.api.opts.WebURL + "/login"
+	req, err := http.NewRequestWithContext(ctx, "GET", loginURL, nil)
+	if err != nil {
+		return fmt.Errorf("build login page request: %w", err)
+	}
+	resp, err := wc.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("fetch login page: %w", err)
 	}
@@ -69,11 +95,11 @@ func (wc *WebClient) ensureLoggedIn() error {
 	// POST /login with form data
 	form := url.Values{
 		"authenticity_token": {csrfToken},
-		"user[login]":       {config.C.Email},
-		"user[password]":    {config.C.Password},
+		"user[login]":       {wc.api.opts.Email},
+		"user[password]":    {wc.api.opts.Password},
 		"user[remember_me]": {"1"},
 	}
-	req, err := http.NewRequest("POST", loginURL, strings.NewReader(form.Encode()))
+	req, err = http.NewRequestWithContext(ctx, "POST", loginURL, strings.NewReader(form.Encode()))
 	if err != nil {
 		return fmt.Errorf("build login request: %w", err)
 	}
@@ -84,14 +110,74 @@ func (wc *WebClient) ensureLoggedIn() error {
 	if err != nil {
 		return fmt.Errorf("login request: %w", err)
 	}
-	io.ReadAll(resp.Body)
+	body, _ = io.ReadAll(resp.Body)
 	resp.Body.Close()
 
 	if resp.StatusCode == 422 || resp.StatusCode == 401 {
-		return fmt.Errorf("login failed (status %d): check LITETRACKER_EMAIL and LITETRACKER_PASSWORD in ~/litetracker-go/.env", resp.StatusCode)
+		return fmt.Errorf("login failed (status %d): check LITETRACKER_EMAIL and LITETRACKER_PASSWORD in ~/litetracker-go/.env: %w", resp.StatusCode, ErrUnauthorized)
+	}
+
+	// Accounts with two-factor auth get a one-time code form instead of
+	// being signed in
+	if otpFieldRegex.Match(body) {
+		if err := wc.submitTOTP(ctx, resp.Request.URL, body); err != nil {
+			return err
+		}
 	}
 
 	wc.loggedIn = true
+	wc.imported = false
+	wc.saveSession()
+	return nil
+}
+
+var (
+	otpFieldRegex   = regexp.MustCompile(`name="user\[otp_attempt\]"`)
+	formActionRegex = regexp.MustCompile(`<form[^>]*action="([^"]*)"`)
+)
+
+// submitTOTP answers the second-factor form on page, which was served at
+// pageURL, with a code generated from the configured TOTP secret.
+func (wc *WebClient) submitTOTP(ctx context.Context, pageURL *url.URL, page []byte) error {
+	if wc.api.opts.TOTPSecret == "" {
+		return fmt.Errorf("LiteTracker asked for a two-factor code: set LITETRACKER_TOTP_SECRET in ~/litetracker-go/.env, or import a browser session with `litetracker login`: %w", ErrUnauthorized)
+	}
+	code, err := TOTP(wc.api.opts.TOTPSecret, time.Now())
+	if err != nil {
+		return fmt.Errorf("LITETRACKER_TOTP_SECRET: %w", err)
+	}
+	matches := csrfRegex.FindSubmatch(page)
+	if len(matches) < 2 {
+		return fmt.Errorf("could not find CSRF token on two-factor page")
+	}
+	action := pageURL
+	if m := formActionRegex.FindSubmatch(page); len(m) == 2 {
+		if u, err := pageURL.Parse(string(m[1])); err == nil {
+			action = u
+		}
+	}
+
+	form := url.Values{
+		"authenticity_token": {string(matches[1])},
+		"user[otp_attempt]":  {code},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", action.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("build two-factor request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("two-factor request: %w", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode == 422 || resp.StatusCode == 401 || otpFieldRegex.Match(body) {
+		return fmt.Errorf("two-factor code rejected (status %d): check LITETRACKER_TOTP_SECRET and the system clock: %w", resp.StatusCode, ErrUnauthorized)
+	}
 	return nil
 }
 
@@ -107,19 +193,19 @@ type apiV1Comment struct {
 	} `json:"data"`
 }
 
-func (wc *WebClient) postComment(storyID int, text string) (Comment, error) {
-	commentURL := fmt.Sprintf("%s/api/v1/stories/%d/comments", config.C.WebURL, storyID)
+func (wc *WebClient) postComment(ctx context.Context, storyID int, text string) (Comment, error) {
+	commentURL := fmt.Sprintf("%s/api/v1/stories/%d/comments", wc.api.opts.WebURL, storyID)
 
 	// Build multipart form data (matches the SPA's FormData format)
 	var buf strings.Builder
 	w := multipart.NewWriter(&buf)
 	w.WriteField("comment[content]", text)
-	w.WriteField("comment[user_id]", strconv.Itoa(config.C.UserID))
+	w.WriteField("comment[user_id]", strconv.Itoa(wc.api.opts.UserID))
 	w.WriteField("comment[commentable_type]", "Story")
 	w.WriteField("comment[commentable_id]", strconv.Itoa(storyID))
 	w.Close()
 
-	req, err := http.NewRequest("POST", commentURL, strings.NewReader(buf.String()))
+	req, err := http.NewRequestWithContext(ctx, "POST", commentURL, strings.NewReader(buf.String()))
 	if err != nil {
 		return Comment{}, fmt.Errorf("build comment request: %w", err)
 	}
@@ -132,8 +218,7 @@ func (wc *WebClient) postComment(storyID int, text string) (Comment, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		b, _ := io.ReadAll(resp.Body)
-		return Comment{}, fmt.Errorf("post comment failed (status %d): %s", resp.StatusCode, string(b))
+		return Comment{}, fmt.Errorf("post comment failed: %w", newSessionError(resp))
 	}
 
 	body, _ := io.ReadAll(resp.Body)
@@ -151,12 +236,100 @@ func (wc *WebClient) postComment(storyID int, text string) (Comment, error) {
 	}, nil
 }
 
-func (wc *WebClient) addLabel(storyID, projectID int, name string) (Label, error) {
-	labelURL := fmt.Sprintf("%s/api/v1/stories/%d/labels", config.C.WebURL, storyID)
+// checkCommentAuthor fetches the comment via the v5 API and refuses to
+// proceed if it was written by someone other than the configured user,
+// unless allowOthers is set.
+func (wc *WebClient) checkCommentAuthor(ctx context.Context, projectID, storyID, commentID int, allowOthers bool) (Comment, error) {
+	comment, err := wc.api.GetComment(ctx, projectID, storyID, commentID)
+	if err != nil {
+		return Comment{}, fmt.Errorf("fetch comment: %w", err)
+	}
+	if allowOthers {
+		return comment, nil
+	}
+	if wc.api.opts.UserID == 0 {
+		return Comment{}, fmt.Errorf("LITETRACKER_USER_ID must be set to verify comment %d is yours", commentID)
+	}
+	if comment.PersonID != wc.api.opts.UserID {
+		return Comment{}, fmt.Errorf("comment %d was written by person %d, not you (%d)", commentID, comment.PersonID, wc.api.opts.UserID)
+	}
+	return comment, nil
+}
+
+func (wc *WebClient) editComment(ctx context.Context, projectID, storyID, commentID int, text string, allowOthers bool) (Comment, error) {
+	if _, err := wc.checkCommentAuthor(ctx, projectID, storyID, commentID, allowOthers); err != nil {
+		return Comment{}, err
+	}
+
+	commentURL := fmt.Sprintf("%s/api/v1/comments/%d", wc.api.opts.WebURL, commentID)
+
+	var buf strings.Builder
+	w := multipart.NewWriter(&buf)
+	w.WriteField("comment[content]", text)
+	w.Close()
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", commentURL, strings.NewReader(buf.String()))
+	if err != nil {
+		return Comment{}, fmt.Errorf("build comment request: %w", err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return Comment{}, fmt.Errorf("edit comment: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return Comment{}, fmt.Errorf("edit comment failed: %w", newSessionError(resp))
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	var result apiV1Comment
+	if err := json.Unmarshal(body, &result); err != nil {
+		return Comment{ID: commentID, Text: text}, nil
+	}
+
+	id, _ := strconv.Atoi(result.Data.ID)
+	return Comment{
+		ID:        id,
+		Text:      result.Data.Attributes.Content,
+		PersonID:  result.Data.Attributes.UserID,
+		CreatedAt: result.Data.Attributes.CreatedAt,
+	}, nil
+}
+
+func (wc *WebClient) deleteComment(ctx context.Context, projectID, storyID, commentID int, allowOthers bool) (Comment, error) {
+	comment, err := wc.checkCommentAuthor(ctx, projectID, storyID, commentID, allowOthers)
+	if err != nil {
+		return Comment{}, err
+	}
+
+	commentURL := fmt.Sprintf("%s/api/v1/comments/%d", wc.api.opts.WebURL, commentID)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", commentURL, nil)
+	if err != nil {
+		return Comment{}, fmt.Errorf("build comment request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return Comment{}, fmt.Errorf("delete comment: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return Comment{}, fmt.Errorf("delete comment failed: %w", newSessionError(resp))
+	}
+	return comment, nil
+}
+
+func (wc *WebClient) addLabel(ctx context.Context, storyID, projectID int, name string) (Label, error) {
+	labelURL := fmt.Sprintf("%s/api/v1/stories/%d/labels", wc.api.opts.WebURL, storyID)
 	payload, _ := json.Marshal(map[string]any{
 		"label": map[string]any{"name": name, "project_id": projectID},
 	})
-	req, err := http.NewRequest("POST", labelURL, strings.NewReader(string(payload)))
+	req, err := http.NewRequestWithContext(ctx, "POST", labelURL, strings.NewReader(string(payload)))
 	if err != nil {
 		return Label{}, fmt.Errorf("build label request: %w", err)
 	}
@@ -169,8 +342,7 @@ func (wc *WebClient) addLabel(storyID, projectID int, name string) (Label, error
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		b, _ := io.ReadAll(resp.Body)
-		return Label{}, fmt.Errorf("add label failed (status %d): %s", resp.StatusCode, string(b))
+		return Label{}, fmt.Errorf("add label failed: %w", newSessionError(resp))
 	}
 
 	body, _ := io.ReadAll(resp.Body)
@@ -189,29 +361,82 @@ func (wc *WebClient) addLabel(storyID, projectID int, name string) (Label, error
 	return Label{ID: id, Name: result.Data.Attributes.Name}, nil
 }
 
-func WebAddLabel(projectID, storyID int, name string) (Label, error) {
-	wc := getWebClient()
+// withWebSession runs fn against the web client while holding its
+// lock, logging in first and retrying once with a fresh session if the
+// server reports the current one has expired.
+func withWebSession[T any](ctx context.Context, wc *WebClient, fn func(wc *WebClient) (T, error)) (T, error) {
 	wc.mu.Lock()
 	defer wc.mu.Unlock()
 
-	if err := wc.ensureLoggedIn(); err != nil {
-		return Label{}, err
+	if err := wc.ensureLoggedIn(ctx); err != nil {
+		var zero T
+		return zero, err
 	}
 
-	label, err := wc.addLabel(storyID, projectID, name)
-	if err != nil && (strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "sign in")) {
+	result, err := fn(wc)
+	if isSessionExpired(err) {
+		// Session expired, re-login and retry
 		wc.loggedIn = false
-		if err := wc.ensureLoggedIn(); err != nil {
-			return Label{}, err
+		if err := wc.ensureLoggedIn(ctx); err != nil {
+			var zero T
+			return zero, err
 		}
-		return wc.addLabel(storyID, projectID, name)
+		return fn(wc)
+	}
+	return result, err
+}
+
+func (c *Client) WebAddLabel(ctx context.Context, projectID, storyID int, name string) (Label, error) {
+	return withWebSession(ctx, c.web, func(wc *WebClient) (Label, error) {
+		return wc.addLabel(ctx, storyID, projectID, name)
+	})
+}
+
+func (wc *WebClient) removeLabel(ctx context.Context, storyID, projectID int, name string) (Label, error) {
+	// Use v5 API to resolve the label name to the ID attached to this story
+	story, err := wc.api.GetStory(ctx, projectID, storyID)
+	if err != nil {
+		return Label{}, fmt.Errorf("fetch story labels: %w", err)
+	}
+	var label Label
+	for _, l := range story.Labels {
+		if strings.EqualFold(l.Name, name) {
+			label = l
+			break
+		}
+	}
+	if label.ID == 0 {
+		return Label{}, fmt.Errorf("%w: story %d has no label %q", ErrNotFound, storyID, name)
+	}
+
+	labelURL := fmt.Sprintf("%s/api/v1/stories/%d/labels/%d", wc.api.opts.WebURL, storyID, label.ID)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", labelURL, nil)
+	if err != nil {
+		return Label{}, fmt.Errorf("build label request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return Label{}, fmt.Errorf("remove label: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return Label{}, fmt.Errorf("remove label failed: %w", newSessionError(resp))
 	}
-	return label, err
+	return label, nil
 }
 
-func (wc *WebClient) addOwner(storyID, projectID, ownerID int) ([]StoryOwner, error) {
+func (c *Client) WebRemoveLabel(ctx context.Context, projectID, storyID int, name string) (Label, error) {
+	return withWebSession(ctx, c.web, func(wc *WebClient) (Label, error) {
+		return wc.removeLabel(ctx, storyID, projectID, name)
+	})
+}
+
+func (wc *WebClient) addOwner(ctx context.Context, storyID, projectID, ownerID int) ([]StoryOwner, error) {
 	// Use v5 API (token auth, always reliable) to get current owners
-	story, err := GetStory(projectID, storyID)
+	story, err := wc.api.GetStory(ctx, projectID, storyID)
 	if err != nil {
 		return nil, fmt.Errorf("fetch story owners: %w", err)
 	}
@@ -227,12 +452,41 @@ func (wc *WebClient) addOwner(storyID, projectID, ownerID int) ([]StoryOwner, er
 	}
 	ids = append(ids, ownerID)
 
-	// PUT story update with new owner_ids via internal API
-	storyURL := fmt.Sprintf("%s/api/v1/stories/%d", config.C.WebURL, storyID)
+	return wc.setOwners(ctx, storyID, ids)
+}
+
+func (wc *WebClient) removeOwner(ctx context.Context, storyID, projectID, ownerID int) ([]StoryOwner, error) {
+	// Use v5 API (token auth, always reliable) to get current owners
+	story, err := wc.api.GetStory(ctx, projectID, storyID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch story owners: %w", err)
+	}
+
+	// Build owner_ids list from story.Owners (v5 API populates Owners, not OwnerIDs)
+	ids := make([]int, 0, len(story.Owners))
+	found := false
+	for _, o := range story.Owners {
+		if o.UserID == ownerID {
+			found = true
+			continue
+		}
+		ids = append(ids, o.UserID)
+	}
+	if !found {
+		// Reported like removeLabel reports a label the story doesn't have
+		return nil, fmt.Errorf("%w: user %d is not an owner of story %d", ErrNotFound, ownerID, storyID)
+	}
+
+	return wc.setOwners(ctx, storyID, ids)
+}
+
+// setOwners replaces the story's owner list via the internal API.
+func (wc *WebClient) setOwners(ctx context.Context, storyID int, ids []int) ([]StoryOwner, error) {
+	storyURL := fmt.Sprintf("%s/api/v1/stories/%d", wc.api.opts.WebURL, storyID)
 	payload, _ := json.Marshal(map[string]any{
 		"story": map[string]any{"owner_ids": ids},
 	})
-	req, err := http.NewRequest("PUT", storyURL, strings.NewReader(string(payload)))
+	req, err := http.NewRequestWithContext(ctx, "PUT", storyURL, strings.NewReader(string(payload)))
 	if err != nil {
 		return nil, fmt.Errorf("build owner request: %w", err)
 	}
@@ -241,13 +495,12 @@ func (wc *WebClient) addOwner(storyID, projectID, ownerID int) ([]StoryOwner, er
 
 	resp, err := wc.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("add owner: %w", err)
+		return nil, fmt.Errorf("update owners: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("add owner failed (status %d): %s", resp.StatusCode, string(b))
+		return nil, fmt.Errorf("update owners failed: %w", newSessionError(resp))
 	}
 
 	body, _ := io.ReadAll(resp.Body)
@@ -258,43 +511,37 @@ func (wc *WebClient) addOwner(storyID, projectID, ownerID int) ([]StoryOwner, er
 	return result.Owners, nil
 }
 
-func WebAddOwner(projectID, storyID, ownerID int) ([]StoryOwner, error) {
-	wc := getWebClient()
-	wc.mu.Lock()
-	defer wc.mu.Unlock()
-
-	if err := wc.ensureLoggedIn(); err != nil {
-		return nil, err
-	}
+func (c *Client) WebAddOwner(ctx context.Context, projectID, storyID, ownerID int) ([]StoryOwner, error) {
+	return withWebSession(ctx, c.web, func(wc *WebClient) ([]StoryOwner, error) {
+		return wc.addOwner(ctx, storyID, projectID, ownerID)
+	})
+}
 
-	owners, err := wc.addOwner(storyID, projectID, ownerID)
-	if err != nil && (strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "sign in")) {
-		wc.loggedIn = false
-		if err := wc.ensureLoggedIn(); err != nil {
-			return nil, err
-		}
-		return wc.addOwner(storyID, projectID, ownerID)
-	}
-	return owners, err
+func (c *Client) WebRemoveOwner(ctx context.Context, projectID, storyID, ownerID int) ([]StoryOwner, error) {
+	return withWebSession(ctx, c.web, func(wc *WebClient) ([]StoryOwner, error) {
+		return wc.removeOwner(ctx, storyID, projectID, ownerID)
+	})
 }
 
-func WebPostComment(projectID, storyID int, text string) (Comment, error) {
-	wc := getWebClient()
-	wc.mu.Lock()
-	defer wc.mu.Unlock()
+func (c *Client) WebPostComment(ctx context.Context, projectID, storyID int, text string) (Comment, error) {
+	return withWebSession(ctx, c.web, func(wc *WebClient) (Comment, error) {
+		return wc.postComment(ctx, storyID, text)
+	})
+}
 
-	if err := wc.ensureLoggedIn(); err != nil {
-		return Comment{}, err
-	}
+// WebEditComment replaces the text of a comment. Unless allowOthers is set,
+// it refuses to edit comments not written by the client's UserID.
+func (c *Client) WebEditComment(ctx context.Context, projectID, storyID, commentID int, text string, allowOthers bool) (Comment, error) {
+	return withWebSession(ctx, c.web, func(wc *WebClient) (Comment, error) {
+		return wc.editComment(ctx, projectID, storyID, commentID, text, allowOthers)
+	})
+}
 
-	comment, err := wc.postComment(storyID, text)
-	if err != nil && (strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "sign in")) {
-		// Session expired, re-login and retry
-		wc.loggedIn = false
-		if err := wc.ensureLoggedIn(); err != nil {
-			return Comment{}, err
-		}
-		return wc.postComment(storyID, text)
-	}
-	return comment, err
+// WebDeleteComment deletes a comment and returns it as it was before
+// deletion. Unless allowOthers is set, it refuses to delete comments not
+// written by the client's UserID.
+func (c *Client) WebDeleteComment(ctx context.Context, projectID, storyID, commentID int, allowOthers bool) (Comment, error) {
+	return withWebSession(ctx, c.web, func(wc *WebClient) (Comment, error) {
+		return wc.deleteComment(ctx, projectID, storyID, commentID, allowOthers)
+	})
 }
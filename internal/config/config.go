@@ -9,17 +9,29 @@ import (
 )
 
 type Config struct {
-	Token          string
-	BaseURL        string
-	WebURL         string
-	Username       string
-	Email          string
-	Password       string
-	ProjectIDs     []int
-	UserID         int
-	PollIntervalMs int
-	DataDir        string
-	ProjectDir     string
+	Token           string
+	BaseURL         string
+	WebURL          string
+	Username        string
+	Email           string
+	Password        string
+	TOTPSecret      string
+	ProjectIDs      []int
+	UserID          int
+	PollIntervalMs  int
+	WatchIntervalMs int
+	RetryMax        int
+	RetryBaseMs     int
+	RetryMaxMs      int
+	RateLimitRPS    float64
+	RateLimitBurst  int
+	CassetteMode    string
+	CassetteDir     string
+	DataDir         string
+	ProjectDir      string
+	SessionFile     string
+	HTTPTokens      []string
+	ReadOnly        bool
 }
 
 var C Config
@@ -46,8 +58,29 @@ func Init() error {
 	C.Username = os.Getenv("LITETRACKER_USERNAME")
 	C.Email = os.Getenv("LITETRACKER_EMAIL")
 	C.Password = os.Getenv("LITETRACKER_PASSWORD")
+	C.TOTPSecret = os.Getenv("LITETRACKER_TOTP_SECRET")
 	C.UserID = envInt("LITETRACKER_USER_ID")
 	C.PollIntervalMs = envIntOrDefault("POLL_INTERVAL_MS", 300000)
+	C.WatchIntervalMs = envIntOrDefault("LITETRACKER_WATCH_INTERVAL_MS", 30000)
+	C.RetryMax = envIntOrDefault("LITETRACKER_RETRY_MAX", 3)
+	C.RetryBaseMs = envIntOrDefault("LITETRACKER_RETRY_BASE_MS", 500)
+	C.RetryMaxMs = envIntOrDefault("LITETRACKER_RETRY_MAX_MS", 30000)
+	C.RateLimitRPS = envFloatOrDefault("LITETRACKER_RATE_LIMIT_RPS", 5)
+	C.RateLimitBurst = envIntOrDefault("LITETRACKER_RATE_LIMIT_BURST", 10)
+	C.ReadOnly, _ = strconv.ParseBool(os.Getenv("LITETRACKER_READ_ONLY"))
+	C.CassetteMode = os.Getenv("LITETRACKER_CASSETTE")
+	C.CassetteDir = os.Getenv("LITETRACKER_CASSETTE_DIR")
+	if C.CassetteDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			C.CassetteDir = filepath.Join(home, "litetracker-go", "cassettes")
+		}
+	}
+
+	for _, t := range strings.Split(os.Getenv("LITETRACKER_HTTP_TOKENS"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			C.HTTPTokens = append(C.HTTPTokens, t)
+		}
+	}
 
 	ids := os.Getenv("LITETRACKER_PROJECT_IDS")
 	for _, s := range strings.Split(ids, ",") {
@@ -65,8 +98,8 @@ func Init() error {
 	return nil
 }
 
-// InitDataDir sets up the data directory for daemon/sync modes.
-// Not needed for MCP serve mode.
+// InitDataDir sets up the data directory, which holds the DuckDB cache for
+// daemon/sync modes and the saved web session for all modes.
 func InitDataDir() error {
 	if dir := os.Getenv("LITETRACKER_DATA_DIR"); dir != "" {
 		C.DataDir = dir
@@ -81,6 +114,7 @@ func InitDataDir() error {
 	if err := os.MkdirAll(C.DataDir, 0o755); err != nil {
 		return fmt.Errorf("create data dir: %w", err)
 	}
+	C.SessionFile = filepath.Join(C.DataDir, "web-session.json")
 	return nil
 }
 
@@ -130,3 +164,12 @@ func envIntOrDefault(key string, def int) int {
 	}
 	return def
 }
+
+func envFloatOrDefault(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return def
+}
@@ -64,6 +64,9 @@ func migrateSchema() error {
 
 	slog.Info("migrating schema", "from", currentVersion, "to", schemaVersion)
 	for _, stmt := range []string{
+		"DROP TABLE IF EXISTS epics",
+		"DROP TABLE IF EXISTS blockers",
+		"DROP TABLE IF EXISTS tasks",
 		"DROP TABLE IF EXISTS comments",
 		"DROP TABLE IF EXISTS stories",
 		"DROP TABLE IF EXISTS schema_version",
@@ -118,6 +121,39 @@ func createTables() error {
 			created_at TIMESTAMP,
 			synced_at TIMESTAMP NOT NULL
 		)`,
+		`CREATE TABLE IF NOT EXISTS tasks (
+			id INTEGER PRIMARY KEY,
+			story_id INTEGER NOT NULL,
+			project_id INTEGER NOT NULL,
+			description VARCHAR,
+			complete BOOLEAN DEFAULT false,
+			position INTEGER,
+			created_at TIMESTAMP,
+			updated_at TIMESTAMP,
+			synced_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS blockers (
+			id INTEGER PRIMARY KEY,
+			story_id INTEGER NOT NULL,
+			project_id INTEGER NOT NULL,
+			description VARCHAR,
+			blocking_story_ids INTEGER[],
+			resolved BOOLEAN DEFAULT false,
+			created_at TIMESTAMP,
+			updated_at TIMESTAMP,
+			synced_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS epics (
+			id INTEGER PRIMARY KEY,
+			project_id INTEGER NOT NULL,
+			name VARCHAR NOT NULL,
+			description VARCHAR,
+			label_name VARCHAR,
+			url VARCHAR,
+			created_at TIMESTAMP,
+			updated_at TIMESTAMP,
+			synced_at TIMESTAMP NOT NULL
+		)`,
 	}
 	for _, s := range stmts {
 		if _, err := conn.Exec(s); err != nil {
@@ -137,6 +173,10 @@ func createIndexes() error {
 		"CREATE INDEX IF NOT EXISTS idx_comments_story ON comments (story_id)",
 		"CREATE INDEX IF NOT EXISTS idx_comments_mentions ON comments (mentions_me)",
 		"CREATE INDEX IF NOT EXISTS idx_comments_created ON comments (created_at DESC)",
+		"CREATE INDEX IF NOT EXISTS idx_tasks_story ON tasks (story_id, position)",
+		"CREATE INDEX IF NOT EXISTS idx_blockers_story ON blockers (story_id)",
+		"CREATE INDEX IF NOT EXISTS idx_blockers_resolved ON blockers (resolved)",
+		"CREATE INDEX IF NOT EXISTS idx_epics_project ON epics (project_id)",
 	}
 	for _, s := range indexes {
 		if _, err := conn.Exec(s); err != nil {
@@ -175,6 +215,26 @@ func createViews() error {
 		JOIN stories s ON s.id = c.story_id
 		ORDER BY c.created_at DESC`,
 
+		`CREATE OR REPLACE VIEW blocked_stories AS
+		SELECT s.id, s.title, s.current_state, s.owner_names, s.is_mine,
+		       b.id AS blocker_id, b.description AS blocker_description,
+		       b.blocking_story_ids, b.created_at AS blocked_since
+		FROM stories s
+		JOIN blockers b ON b.story_id = s.id AND b.resolved = false
+		ORDER BY b.created_at`,
+
+		`CREATE OR REPLACE VIEW epic_progress AS
+		SELECT e.id, e.project_id, e.name, e.label_name,
+		  COUNT(s.id) AS total_stories,
+		  COUNT(s.id) FILTER (WHERE s.current_state = 'accepted') AS accepted_stories,
+		  COALESCE(SUM(s.estimate), 0) AS total_points,
+		  COALESCE(SUM(s.estimate) FILTER (WHERE s.current_state = 'accepted'), 0) AS accepted_points
+		FROM epics e
+		LEFT JOIN stories s ON s.project_id = e.project_id
+		  AND list_contains(string_split(s.label_names, ', '), e.label_name)
+		GROUP BY e.id, e.project_id, e.name, e.label_name
+		ORDER BY e.project_id, e.name`,
+
 		`CREATE OR REPLACE VIEW story_stats AS
 		SELECT
 		  COUNT(*) AS total_stories,
@@ -316,6 +376,120 @@ func UpsertComment(c CommentRow) error {
 	return err
 }
 
+type TaskRow struct {
+	ID          int
+	StoryID     int
+	ProjectID   int
+	Description *string
+	Complete    bool
+	Position    int
+	CreatedAt   string
+	UpdatedAt   string
+}
+
+func UpsertTask(t TaskRow) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	createdAt := ParseApiDate(t.CreatedAt)
+	updatedAt := ParseApiDate(t.UpdatedAt)
+
+	_, err := conn.Exec(
+		`INSERT INTO tasks (id, story_id, project_id, description, complete, position, created_at, updated_at, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, TRY_CAST(? AS TIMESTAMP), TRY_CAST(? AS TIMESTAMP), TRY_CAST(? AS TIMESTAMP))
+		ON CONFLICT(id) DO UPDATE SET
+			description = excluded.description,
+			complete = excluded.complete,
+			position = excluded.position,
+			updated_at = excluded.updated_at,
+			synced_at = excluded.synced_at`,
+		t.ID, t.StoryID, t.ProjectID, t.Description, t.Complete, t.Position,
+		ptrOrNil(createdAt), ptrOrNil(updatedAt), now,
+	)
+	return err
+}
+
+// DeleteTasksExcept removes the tasks of storyID whose IDs are not in keep,
+// which are those deleted in LiteTracker since the last sync.
+func DeleteTasksExcept(storyID int, keep []int) error {
+	_, err := conn.Exec(
+		"DELETE FROM tasks WHERE story_id = ? AND NOT list_contains(TRY_CAST(? AS INTEGER[]), id)",
+		storyID, intListLiteral(keep),
+	)
+	return err
+}
+
+type BlockerRow struct {
+	ID               int
+	StoryID          int
+	ProjectID        int
+	Description      *string
+	BlockingStoryIDs []int
+	Resolved         bool
+	CreatedAt        string
+	UpdatedAt        string
+}
+
+func UpsertBlocker(b BlockerRow) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	createdAt := ParseApiDate(b.CreatedAt)
+	updatedAt := ParseApiDate(b.UpdatedAt)
+
+	_, err := conn.Exec(
+		`INSERT INTO blockers (id, story_id, project_id, description, blocking_story_ids, resolved, created_at, updated_at, synced_at)
+		VALUES (?, ?, ?, ?, TRY_CAST(? AS INTEGER[]), ?, TRY_CAST(? AS TIMESTAMP), TRY_CAST(? AS TIMESTAMP), TRY_CAST(? AS TIMESTAMP))
+		ON CONFLICT(id) DO UPDATE SET
+			description = excluded.description,
+			blocking_story_ids = excluded.blocking_story_ids,
+			resolved = excluded.resolved,
+			updated_at = excluded.updated_at,
+			synced_at = excluded.synced_at`,
+		b.ID, b.StoryID, b.ProjectID, b.Description, intListLiteral(b.BlockingStoryIDs), b.Resolved,
+		ptrOrNil(createdAt), ptrOrNil(updatedAt), now,
+	)
+	return err
+}
+
+// DeleteBlockersExcept removes the blockers of storyID whose IDs are not in
+// keep, which are those deleted in LiteTracker since the last sync.
+func DeleteBlockersExcept(storyID int, keep []int) error {
+	_, err := conn.Exec(
+		"DELETE FROM blockers WHERE story_id = ? AND NOT list_contains(TRY_CAST(? AS INTEGER[]), id)",
+		storyID, intListLiteral(keep),
+	)
+	return err
+}
+
+type EpicRow struct {
+	ID          int
+	ProjectID   int
+	Name        string
+	Description *string
+	LabelName   *string
+	URL         *string
+	CreatedAt   string
+	UpdatedAt   string
+}
+
+func UpsertEpic(e EpicRow) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	createdAt := ParseApiDate(e.CreatedAt)
+	updatedAt := ParseApiDate(e.UpdatedAt)
+
+	_, err := conn.Exec(
+		`INSERT INTO epics (id, project_id, name, description, label_name, url, created_at, updated_at, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, TRY_CAST(? AS TIMESTAMP), TRY_CAST(? AS TIMESTAMP), TRY_CAST(? AS TIMESTAMP))
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			description = excluded.description,
+			label_name = excluded.label_name,
+			url = excluded.url,
+			updated_at = excluded.updated_at,
+			synced_at = excluded.synced_at`,
+		e.ID, e.ProjectID, e.Name, e.Description, e.LabelName, e.URL,
+		ptrOrNil(createdAt), ptrOrNil(updatedAt), now,
+	)
+	return err
+}
+
 func MarkStoryMentionsMe(storyID int) error {
 	_, err := conn.Exec("UPDATE stories SET mentions_me = true WHERE id = ?", storyID)
 	return err
@@ -353,3 +527,13 @@ func ptrOrNil(s *string) any {
 	}
 	return *s
 }
+
+// intListLiteral renders ids as a DuckDB list literal such as "[1, 2]", to
+// be cast to INTEGER[] in SQL.
+func intListLiteral(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
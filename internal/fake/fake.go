@@ -0,0 +1,446 @@
+// Package fake is an in-memory stand-in for LiteTracker, for tests that
+// exercise the api, mcp and sync packages without network access.
+//
+// It serves the v5 endpoints used by the api package under /services/v5
+// (token authenticated) and the /login and /api/v1 endpoints used by the
+// web session client (CSRF-protected login, cookie sessions).
+package fake
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+)
+
+// Credentials the fake accepts. Options returns them pre-filled.
+const (
+	Token    = "fake-token"
+	Username = "dev"
+	Email    = "dev@example.com"
+	Password = "hunter2"
+	UserID   = 100
+	UserName = "Dev User"
+)
+
+// dateLayout matches the date format LiteTracker's API returns, which
+// db.ParseApiDate understands.
+const dateLayout = "02 Jan 2006, 03:04PM"
+
+// Server is a running fake LiteTracker. Its state can be seeded and
+// inspected through its methods while clients talk to it over HTTP.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	nextID   int
+	projects map[int]*project
+	people   map[int]api.Person
+	sessions map[string]*session
+	totp     string // TOTP secret, when two-factor login is enabled
+	logins   int
+	requests int
+	failures []int
+}
+
+type project struct {
+	api.Project
+	stories     []*api.Story // in priority order
+	comments    map[int][]api.Comment
+	tasks       map[int][]api.Task
+	blockers    map[int][]api.Blocker
+	epics       []api.Epic
+	labels      map[string]api.Label
+	memberships []api.Membership
+	iterations  []iteration
+	activity    []api.Activity
+}
+
+type iteration struct {
+	api.Iteration
+	storyIDs []int
+}
+
+type session struct {
+	csrf       string
+	loggedIn   bool
+	otpPending bool // password accepted, waiting for the two-factor code
+}
+
+// NewServer starts a fake LiteTracker with the configured user as its only
+// person. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		nextID:   1000,
+		projects: map[int]*project{},
+		people:   map[int]api.Person{},
+		sessions: map[string]*session{},
+	}
+	s.people[UserID] = api.Person{ID: UserID, Name: UserName, Initials: "DU", Kind: "person"}
+
+	mux := http.NewServeMux()
+	s.registerV5(mux)
+	s.registerWeb(mux)
+	s.Server = httptest.NewServer(s.count(mux))
+	return s
+}
+
+// count tallies requests and serves any failures queued by FailNext.
+func (s *Server) count(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		fail := 0
+		if len(s.failures) > 0 {
+			fail, s.failures = s.failures[0], s.failures[1:]
+		}
+		s.mu.Unlock()
+		if fail != 0 {
+			writeError(w, fail, "fake_failure", http.StatusText(fail))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// FailNext makes the next len(statuses) requests fail with these statuses,
+// in order, before they reach any handler.
+func (s *Server) FailNext(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, statuses...)
+}
+
+// Options returns api.Options that point a client at this server with
+// valid credentials.
+func (s *Server) Options() api.Options {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return api.Options{
+		Token:      Token,
+		BaseURL:    s.URL + "/services/v5",
+		WebURL:     s.URL,
+		Email:      Email,
+		Password:   Password,
+		TOTPSecret: s.totp,
+		UserID:     UserID,
+	}
+}
+
+// EnableTOTP turns on two-factor login: after the password, /login asks
+// for a one-time code generated from secret.
+func (s *Server) EnableTOTP(secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.totp = secret
+}
+
+// BrowserSession starts a signed-in web session, as if the user had logged
+// in with a browser, and returns its cookie for importing into a client.
+func (s *Server) BrowserSession() *http.Cookie {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := rand.Text()
+	s.sessions[id] = &session{loggedIn: true}
+	return &http.Cookie{Name: sessionCookie, Value: id}
+}
+
+// Logins returns how many successful password logins the server has seen.
+func (s *Server) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins
+}
+
+// Requests returns how many requests the server has handled.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// ExpireSessions logs out every web session, as if the cookies expired.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = map[string]*session{}
+}
+
+func (s *Server) id() int {
+	s.nextID++
+	return s.nextID
+}
+
+func now() string {
+	return time.Now().UTC().Format(dateLayout)
+}
+
+// AddPerson adds a person who can then be made a project member.
+func (s *Server) AddPerson(name, initials string) api.Person {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := api.Person{ID: s.id(), Name: name, Initials: initials, Kind: "person"}
+	s.people[p.ID] = p
+	return p
+}
+
+// AddProject creates a project with the configured user as its owner.
+func (s *Server) AddProject(title string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := &project{
+		Project:  api.Project{ID: s.id(), Title: title},
+		comments: map[int][]api.Comment{},
+		tasks:    map[int][]api.Task{},
+		blockers: map[int][]api.Blocker{},
+		labels:   map[string]api.Label{},
+	}
+	p.memberships = append(p.memberships, api.Membership{Person: s.people[UserID], Role: "owner"})
+	s.projects[p.ID] = p
+	return p.ID
+}
+
+// AddMember adds a person to a project.
+func (s *Server) AddMember(projectID int, person api.Person, role string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.projects[projectID]
+	p.memberships = append(p.memberships, api.Membership{Person: person, Role: role})
+}
+
+// AddStory appends a story to a project's backlog. Title, StoryType,
+// CurrentState, Estimate, Labels (by name), OwnerIDs and AcceptedAt are
+// taken from story; the ID and other timestamps are assigned.
+func (s *Server) AddStory(projectID int, story api.Story) api.Story {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.projects[projectID]
+	st := s.newStory(p, story)
+	return s.render(p, st)
+}
+
+func (s *Server) newStory(p *project, story api.Story) *api.Story {
+	st := story
+	st.ID = s.id()
+	st.ProjectID = &p.ID
+	if st.StoryType == "" {
+		st.StoryType = "feature"
+	}
+	if st.CurrentState == "" {
+		st.CurrentState = "unstarted"
+	}
+	st.CreatedAt, st.UpdatedAt = now(), now()
+	if st.CurrentState == "accepted" && st.AcceptedAt == "" {
+		st.AcceptedAt = now()
+	}
+	st.URL = fmt.Sprintf("%s/story/show/%d", s.URL, st.ID)
+	names := make([]string, len(st.Labels))
+	for i, l := range st.Labels {
+		names[i] = l.Name
+	}
+	st.Labels = nil
+	for _, n := range names {
+		st.Labels = append(st.Labels, s.label(p, n))
+	}
+	st.Owners = nil
+	p.stories = append(p.stories, &st)
+	return &st
+}
+
+// label returns the project's label with this name, creating it if needed.
+func (s *Server) label(p *project, name string) api.Label {
+	if l, ok := p.labels[name]; ok {
+		return l
+	}
+	l := api.Label{ID: s.id(), Name: name, Kind: "label"}
+	p.labels[name] = l
+	return l
+}
+
+// AddComment adds a comment to a story as the given person.
+func (s *Server) AddComment(projectID, storyID, personID int, text string) api.Comment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.projects[projectID]
+	return s.addComment(p, storyID, personID, text)
+}
+
+func (s *Server) addComment(p *project, storyID, personID int, text string) api.Comment {
+	person := s.people[personID]
+	c := api.Comment{ID: s.id(), Text: text, PersonID: personID, Person: &person, CreatedAt: now(), UpdatedAt: now()}
+	p.comments[storyID] = append(p.comments[storyID], c)
+	if st := p.story(storyID); st != nil {
+		s.record(p, "comment_create_activity", personID, fmt.Sprintf("%s added comment: %q", person.Name, text), st)
+	}
+	return c
+}
+
+// AddTask adds a task to the end of a story's checklist.
+func (s *Server) AddTask(projectID, storyID int, description string, complete bool) api.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.projects[projectID]
+	return s.addTask(p, storyID, description, complete, 0)
+}
+
+func (s *Server) addTask(p *project, storyID int, description string, complete bool, position int) api.Task {
+	t := api.Task{ID: s.id(), StoryID: storyID, Description: description, Complete: complete, CreatedAt: now(), UpdatedAt: now()}
+	tasks := p.tasks[storyID]
+	if position < 1 || position > len(tasks)+1 {
+		position = len(tasks) + 1
+	}
+	tasks = slices.Insert(tasks, position-1, t)
+	p.tasks[storyID] = renumber(tasks)
+	return p.tasks[storyID][position-1]
+}
+
+func renumber(tasks []api.Task) []api.Task {
+	for i := range tasks {
+		tasks[i].Position = i + 1
+	}
+	return tasks
+}
+
+// AddBlocker records that storyID is blocked, with a description such as
+// "#123" referring to the blocking story.
+func (s *Server) AddBlocker(projectID, storyID int, description string) api.Blocker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.projects[projectID]
+	b := api.Blocker{ID: s.id(), StoryID: storyID, Description: description, CreatedAt: now(), UpdatedAt: now()}
+	p.blockers[storyID] = append(p.blockers[storyID], b)
+	return b
+}
+
+// RemoveBlocker deletes a blocker, as removing it in the LiteTracker UI
+// does.
+func (s *Server) RemoveBlocker(projectID, storyID, blockerID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.projects[projectID]
+	p.blockers[storyID] = slices.DeleteFunc(p.blockers[storyID], func(b api.Blocker) bool { return b.ID == blockerID })
+}
+
+// AddEpic creates an epic grouping the stories labelled labelName.
+func (s *Server) AddEpic(projectID int, name, labelName string) api.Epic {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.projects[projectID]
+	return s.addEpic(p, name, "", labelName)
+}
+
+func (s *Server) addEpic(p *project, name, description, labelName string) api.Epic {
+	if labelName == "" {
+		labelName = name
+	}
+	id := s.id()
+	e := api.Epic{
+		ID: id, ProjectID: p.ID, Name: name, Description: description,
+		Label: s.label(p, labelName), URL: fmt.Sprintf("%s/epic/show/%d", s.URL, id),
+		CreatedAt: now(), UpdatedAt: now(),
+	}
+	p.epics = append(p.epics, e)
+	return e
+}
+
+// AddIteration adds an iteration of the given kind ("current", "backlog"
+// or "done") holding the given stories.
+func (s *Server) AddIteration(projectID int, kind string, start, finish time.Time, velocity float64, storyIDs ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.projects[projectID]
+	it := iteration{
+		Iteration: api.Iteration{
+			Number: len(p.iterations) + 1, ProjectID: projectID, Kind: kind, Length: 1, TeamStrength: 1,
+			Start: start.UTC().Format(time.RFC3339), Finish: finish.UTC().Format(time.RFC3339), Velocity: velocity,
+		},
+		storyIDs: storyIDs,
+	}
+	p.iterations = append(p.iterations, it)
+}
+
+// AddActivity appends an activity to a project's feed. OccurredAt should be
+// RFC 3339; it defaults to now.
+func (s *Server) AddActivity(projectID int, a api.Activity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.projects[projectID]
+	if a.OccurredAt == "" {
+		a.OccurredAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	if a.GUID == "" {
+		a.GUID = fmt.Sprintf("%d_%d", projectID, s.id())
+	}
+	p.activity = append(p.activity, a)
+}
+
+// record appends an activity for a change made through the fake's API.
+func (s *Server) record(p *project, kind string, personID int, message string, st *api.Story) {
+	p.activity = append(p.activity, api.Activity{
+		Kind:        kind,
+		GUID:        fmt.Sprintf("%d_%d", p.ID, s.id()),
+		Message:     message,
+		PerformedBy: s.people[personID],
+		OccurredAt:  time.Now().UTC().Format(time.RFC3339),
+		PrimaryResources: []api.ActivityResource{
+			{Kind: "story", ID: st.ID, Name: st.Title, StoryType: st.StoryType, URL: st.URL},
+		},
+	})
+}
+
+// Story returns the current state of a story, as the v5 API would.
+func (s *Server) Story(projectID, storyID int) (api.Story, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.projects[projectID]
+	st := p.story(storyID)
+	if st == nil {
+		return api.Story{}, false
+	}
+	return s.render(p, st), true
+}
+
+// Comments returns the comments on a story.
+func (s *Server) Comments(projectID, storyID int) []api.Comment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.projects[projectID].comments[storyID])
+}
+
+// StoryOrder returns the IDs of a project's stories in priority order.
+func (s *Server) StoryOrder(projectID int) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []int
+	for _, st := range s.projects[projectID].stories {
+		ids = append(ids, st.ID)
+	}
+	return ids
+}
+
+func (p *project) story(id int) *api.Story {
+	for _, st := range p.stories {
+		if st.ID == id {
+			return st
+		}
+	}
+	return nil
+}
+
+// render fills in the owner details the v5 API returns with a story.
+func (s *Server) render(p *project, st *api.Story) api.Story {
+	out := *st
+	out.Labels = slices.Clone(st.Labels)
+	out.OwnerIDs = slices.Clone(st.OwnerIDs)
+	out.Owners = nil
+	for _, id := range st.OwnerIDs {
+		person := s.people[id]
+		out.Owners = append(out.Owners, api.StoryOwner{ID: id, UserID: id, Name: person.Name, Initials: person.Initials})
+	}
+	return out
+}
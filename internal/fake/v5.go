@@ -0,0 +1,634 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+)
+
+func (s *Server) registerV5(mux *http.ServeMux) {
+	const p = "/services/v5"
+	mux.HandleFunc("GET "+p+"/me", s.v5(s.getMe))
+	mux.HandleFunc("GET "+p+"/projects", s.v5(s.listProjects))
+	mux.HandleFunc("GET "+p+"/projects/{pid}", s.v5(s.getProject))
+	mux.HandleFunc("GET "+p+"/projects/{pid}/stories", s.v5(s.listStories))
+	mux.HandleFunc("POST "+p+"/projects/{pid}/stories", s.v5(s.createStory))
+	mux.HandleFunc("GET "+p+"/projects/{pid}/stories/{sid}", s.v5(s.getStory))
+	mux.HandleFunc("PUT "+p+"/projects/{pid}/stories/{sid}", s.v5(s.updateStory))
+	mux.HandleFunc("GET "+p+"/projects/{pid}/stories/{sid}/comments", s.v5(s.listComments))
+	mux.HandleFunc("POST "+p+"/projects/{pid}/stories/{sid}/comments", s.v5(s.createComment))
+	mux.HandleFunc("GET "+p+"/projects/{pid}/stories/{sid}/comments/{cid}", s.v5(s.getComment))
+	mux.HandleFunc("GET "+p+"/projects/{pid}/stories/{sid}/tasks", s.v5(s.listTasks))
+	mux.HandleFunc("POST "+p+"/projects/{pid}/stories/{sid}/tasks", s.v5(s.createTask))
+	mux.HandleFunc("PUT "+p+"/projects/{pid}/stories/{sid}/tasks/{tid}", s.v5(s.updateTask))
+	mux.HandleFunc("DELETE "+p+"/projects/{pid}/stories/{sid}/tasks/{tid}", s.v5(s.deleteTask))
+	mux.HandleFunc("GET "+p+"/projects/{pid}/stories/{sid}/blockers", s.v5(s.listBlockers))
+	mux.HandleFunc("POST "+p+"/projects/{pid}/stories/{sid}/blockers", s.v5(s.createBlocker))
+	mux.HandleFunc("PUT "+p+"/projects/{pid}/stories/{sid}/blockers/{bid}", s.v5(s.updateBlocker))
+	mux.HandleFunc("GET "+p+"/projects/{pid}/epics", s.v5(s.listEpics))
+	mux.HandleFunc("POST "+p+"/projects/{pid}/epics", s.v5(s.createEpic))
+	mux.HandleFunc("GET "+p+"/projects/{pid}/epics/{eid}", s.v5(s.getEpic))
+	mux.HandleFunc("GET "+p+"/projects/{pid}/iterations", s.v5(s.listIterations))
+	mux.HandleFunc("GET "+p+"/projects/{pid}/memberships", s.v5(s.listMemberships))
+	mux.HandleFunc("GET "+p+"/projects/{pid}/activity", s.v5(s.listActivity))
+}
+
+// v5 checks the API token and holds the server lock for the handler.
+func (s *Server) v5(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-TrackerToken") != Token {
+			writeError(w, http.StatusUnauthorized, "invalid_authentication", "Invalid authentication credentials were presented.")
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		h(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{"code": code, "kind": "error", "error": message})
+}
+
+func notFound(w http.ResponseWriter) {
+	writeError(w, http.StatusNotFound, "unfound_resource", "The object you tried to access could not be found.")
+}
+
+func badRequest(w http.ResponseWriter, message string) {
+	writeError(w, http.StatusBadRequest, "invalid_parameter", message)
+}
+
+func pathInt(r *http.Request, name string) int {
+	n, _ := strconv.Atoi(r.PathValue(name))
+	return n
+}
+
+func queryInt(r *http.Request, name string, def int) int {
+	if n, err := strconv.Atoi(r.URL.Query().Get(name)); err == nil {
+		return n
+	}
+	return def
+}
+
+// project looks up the {pid} path value, writing a 404 if there is no such
+// project.
+func (s *Server) project(w http.ResponseWriter, r *http.Request) *project {
+	p := s.projects[pathInt(r, "pid")]
+	if p == nil {
+		notFound(w)
+	}
+	return p
+}
+
+// story looks up the {pid} and {sid} path values, writing a 404 if either
+// does not exist.
+func (s *Server) story(w http.ResponseWriter, r *http.Request) (*project, *api.Story) {
+	p := s.project(w, r)
+	if p == nil {
+		return nil, nil
+	}
+	st := p.story(pathInt(r, "sid"))
+	if st == nil {
+		notFound(w)
+	}
+	return p, st
+}
+
+// page writes one page of items with LiteTracker's pagination headers,
+// defaultLimit long unless the request asks for another limit.
+func page[T any](w http.ResponseWriter, r *http.Request, items []T, defaultLimit int) {
+	offset := max(queryInt(r, "offset", 0), 0)
+	limit := queryInt(r, "limit", defaultLimit)
+	total := len(items)
+	items = items[min(offset, total):min(offset+limit, total)]
+	h := w.Header()
+	h.Set("X-Tracker-Pagination-Total", strconv.Itoa(total))
+	h.Set("X-Tracker-Pagination-Offset", strconv.Itoa(offset))
+	h.Set("X-Tracker-Pagination-Limit", strconv.Itoa(limit))
+	h.Set("X-Tracker-Pagination-Returned", strconv.Itoa(len(items)))
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) getMe(w http.ResponseWriter, r *http.Request) {
+	me := api.Me{ID: UserID, Name: UserName, Initials: "DU", Username: Username, Email: Email}
+	for _, id := range s.projectIDs() {
+		p := s.projects[id]
+		for _, m := range p.memberships {
+			if m.Person.ID == UserID {
+				me.Projects = append(me.Projects, api.ProjectMembership{ProjectID: p.ID, ProjectName: p.Title, Role: m.Role})
+			}
+		}
+	}
+	// Like LiteTracker, /me includes the account's API token
+	writeJSON(w, http.StatusOK, struct {
+		api.Me
+		APIToken string `json:"api_token"`
+	}{me, Token})
+}
+
+func (s *Server) projectIDs() []int {
+	var ids []int
+	for id := range s.projects {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request) {
+	out := []api.Project{}
+	for _, id := range s.projectIDs() {
+		out = append(out, s.projects[id].Project)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request) {
+	if p := s.project(w, r); p != nil {
+		writeJSON(w, http.StatusOK, p.Project)
+	}
+}
+
+func (s *Server) listStories(w http.ResponseWriter, r *http.Request) {
+	p := s.project(w, r)
+	if p == nil {
+		return
+	}
+	q := r.URL.Query()
+	state := q.Get("with_state")
+	ownedBy := queryInt(r, "owned_by", 0)
+	f := parseFilter(q.Get("filter"))
+
+	out := []api.Story{}
+	for _, st := range p.stories {
+		if state != "" && st.CurrentState != state {
+			continue
+		}
+		if len(f.states) > 0 && !slices.Contains(f.states, st.CurrentState) {
+			continue
+		}
+		if len(f.types) > 0 && !slices.Contains(f.types, st.StoryType) {
+			continue
+		}
+		if !f.acceptedSince.IsZero() {
+			accepted, err := time.Parse(dateLayout, st.AcceptedAt)
+			if err != nil || accepted.Before(f.acceptedSince) {
+				continue
+			}
+		}
+		if ownedBy != 0 && !slices.Contains(st.OwnerIDs, ownedBy) {
+			continue
+		}
+		if !hasLabels(st, f.labels) {
+			continue
+		}
+		out = append(out, s.render(p, st))
+	}
+	page(w, r, out, 100)
+}
+
+// storyFilter is a parsed search filter.
+type storyFilter struct {
+	labels        []string
+	states        []string
+	types         []string
+	acceptedSince time.Time
+}
+
+// parseFilter understands the label:, state:, type: and accepted_since:
+// terms of Tracker's search syntax, which is all the clients under test use.
+func parseFilter(filter string) storyFilter {
+	var f storyFilter
+	for filter = strings.TrimSpace(filter); filter != ""; filter = strings.TrimSpace(filter) {
+		key, rest, ok := strings.Cut(filter, ":")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, filter = rest[1:], ""
+			} else {
+				value, filter = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, filter, _ = strings.Cut(rest, " ")
+		}
+		switch key {
+		case "label":
+			f.labels = append(f.labels, value)
+		case "state":
+			f.states = append(f.states, value)
+		case "type":
+			f.types = append(f.types, value)
+		case "accepted_since":
+			f.acceptedSince, _ = time.Parse("01/02/2006", value)
+		}
+	}
+	return f
+}
+
+func hasLabels(st *api.Story, names []string) bool {
+	for _, n := range names {
+		if !slices.ContainsFunc(st.Labels, func(l api.Label) bool { return strings.EqualFold(l.Name, n) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) getStory(w http.ResponseWriter, r *http.Request) {
+	p, st := s.story(w, r)
+	if st == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.render(p, st))
+}
+
+func (s *Server) createStory(w http.ResponseWriter, r *http.Request) {
+	p := s.project(w, r)
+	if p == nil {
+		return
+	}
+	var params struct {
+		Name        string      `json:"name"`
+		Description string      `json:"description"`
+		StoryType   string      `json:"story_type"`
+		Estimate    *int        `json:"estimate"`
+		Labels      []api.Label `json:"labels"`
+		OwnerIDs    []int       `json:"owner_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil || params.Name == "" {
+		badRequest(w, "name is required")
+		return
+	}
+	st := s.newStory(p, api.Story{
+		Title: params.Name, Description: params.Description, StoryType: params.StoryType,
+		Estimate: params.Estimate, Labels: params.Labels, OwnerIDs: params.OwnerIDs,
+	})
+	st.RequestedByID = ptr(UserID)
+	s.record(p, "story_create_activity", UserID, fmt.Sprintf("%s added this %s", UserName, st.StoryType), st)
+	writeJSON(w, http.StatusOK, s.render(p, st))
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func (s *Server) updateStory(w http.ResponseWriter, r *http.Request) {
+	p, st := s.story(w, r)
+	if st == nil {
+		return
+	}
+	var u api.StoryUpdate
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	if u.CurrentState != nil && *u.CurrentState != "unscheduled" && !slices.Contains(api.StoryStates, *u.CurrentState) {
+		badRequest(w, fmt.Sprintf("invalid current_state %q", *u.CurrentState))
+		return
+	}
+	if u.BeforeID != nil || u.AfterID != nil {
+		if !p.move(st, u.BeforeID, u.AfterID) {
+			badRequest(w, "before_id or after_id refers to a story not in this project")
+			return
+		}
+	}
+
+	var changed []string
+	set := func(field string, dst *string, v *string) {
+		if v != nil {
+			*dst = *v
+			changed = append(changed, field)
+		}
+	}
+	set("name", &st.Title, u.Title)
+	set("description", &st.Description, u.Description)
+	set("story_type", &st.StoryType, u.StoryType)
+	set("current_state", &st.CurrentState, u.CurrentState)
+	set("story_priority", &st.StoryPriority, u.StoryPriority)
+	if u.ClearEstimate {
+		st.Estimate = nil
+		changed = append(changed, "estimate")
+	} else if u.Estimate != nil {
+		st.Estimate = ptr(*u.Estimate)
+		changed = append(changed, "estimate")
+	}
+	st.UpdatedAt = now()
+	if u.CurrentState != nil {
+		st.AcceptedAt = ""
+		if *u.CurrentState == "accepted" {
+			st.AcceptedAt = now()
+		}
+	}
+
+	if len(changed) > 0 {
+		message := fmt.Sprintf("%s edited %s of this %s", UserName, strings.Join(changed, ", "), st.StoryType)
+		if u.CurrentState != nil {
+			message = fmt.Sprintf("%s %s this %s", UserName, *u.CurrentState, st.StoryType)
+		}
+		s.record(p, "story_update_activity", UserID, message, st)
+	}
+	writeJSON(w, http.StatusOK, s.render(p, st))
+}
+
+// move repositions st directly before or after another story.
+func (p *project) move(st *api.Story, beforeID, afterID *int) bool {
+	target := 0
+	if beforeID != nil {
+		target = *beforeID
+	} else {
+		target = *afterID
+	}
+	if p.story(target) == nil || target == st.ID {
+		return false
+	}
+	p.stories = slices.DeleteFunc(p.stories, func(x *api.Story) bool { return x == st })
+	i := slices.IndexFunc(p.stories, func(x *api.Story) bool { return x.ID == target })
+	if afterID != nil {
+		i++
+	}
+	p.stories = slices.Insert(p.stories, i, st)
+	return true
+}
+
+func (s *Server) listComments(w http.ResponseWriter, r *http.Request) {
+	p, st := s.story(w, r)
+	if st == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, append([]api.Comment{}, p.comments[st.ID]...))
+}
+
+func (s *Server) createComment(w http.ResponseWriter, r *http.Request) {
+	p, st := s.story(w, r)
+	if st == nil {
+		return
+	}
+	var params struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil || params.Text == "" {
+		badRequest(w, "text is required")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.addComment(p, st.ID, UserID, params.Text))
+}
+
+func (s *Server) getComment(w http.ResponseWriter, r *http.Request) {
+	p, st := s.story(w, r)
+	if st == nil {
+		return
+	}
+	i := commentIndex(p.comments[st.ID], pathInt(r, "cid"))
+	if i < 0 {
+		notFound(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, p.comments[st.ID][i])
+}
+
+func commentIndex(comments []api.Comment, id int) int {
+	return slices.IndexFunc(comments, func(c api.Comment) bool { return c.ID == id })
+}
+
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	p, st := s.story(w, r)
+	if st == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, append([]api.Task{}, p.tasks[st.ID]...))
+}
+
+func (s *Server) createTask(w http.ResponseWriter, r *http.Request) {
+	p, st := s.story(w, r)
+	if st == nil {
+		return
+	}
+	var params struct {
+		Description string `json:"description"`
+		Position    int    `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil || params.Description == "" {
+		badRequest(w, "description is required")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.addTask(p, st.ID, params.Description, false, params.Position))
+}
+
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request) {
+	p, st := s.story(w, r)
+	if st == nil {
+		return
+	}
+	tasks := p.tasks[st.ID]
+	i := slices.IndexFunc(tasks, func(t api.Task) bool { return t.ID == pathInt(r, "tid") })
+	if i < 0 {
+		notFound(w)
+		return
+	}
+	var u api.TaskUpdate
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	t := tasks[i]
+	if u.Description != nil {
+		t.Description = *u.Description
+	}
+	if u.Complete != nil {
+		t.Complete = *u.Complete
+	}
+	t.UpdatedAt = now()
+	tasks[i] = t
+	if u.Position != nil {
+		pos := min(max(*u.Position, 1), len(tasks))
+		tasks = slices.Delete(tasks, i, i+1)
+		tasks = slices.Insert(tasks, pos-1, t)
+		i = pos - 1
+	}
+	p.tasks[st.ID] = renumber(tasks)
+	writeJSON(w, http.StatusOK, p.tasks[st.ID][i])
+}
+
+func (s *Server) deleteTask(w http.ResponseWriter, r *http.Request) {
+	p, st := s.story(w, r)
+	if st == nil {
+		return
+	}
+	tasks := p.tasks[st.ID]
+	i := slices.IndexFunc(tasks, func(t api.Task) bool { return t.ID == pathInt(r, "tid") })
+	if i < 0 {
+		notFound(w)
+		return
+	}
+	p.tasks[st.ID] = renumber(slices.Delete(tasks, i, i+1))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listBlockers(w http.ResponseWriter, r *http.Request) {
+	p, st := s.story(w, r)
+	if st == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, append([]api.Blocker{}, p.blockers[st.ID]...))
+}
+
+func (s *Server) createBlocker(w http.ResponseWriter, r *http.Request) {
+	p, st := s.story(w, r)
+	if st == nil {
+		return
+	}
+	var params struct {
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil || params.Description == "" {
+		badRequest(w, "description is required")
+		return
+	}
+	b := api.Blocker{ID: s.id(), StoryID: st.ID, Description: params.Description, CreatedAt: now(), UpdatedAt: now()}
+	p.blockers[st.ID] = append(p.blockers[st.ID], b)
+	writeJSON(w, http.StatusOK, b)
+}
+
+func (s *Server) updateBlocker(w http.ResponseWriter, r *http.Request) {
+	p, st := s.story(w, r)
+	if st == nil {
+		return
+	}
+	blockers := p.blockers[st.ID]
+	i := slices.IndexFunc(blockers, func(b api.Blocker) bool { return b.ID == pathInt(r, "bid") })
+	if i < 0 {
+		notFound(w)
+		return
+	}
+	var u api.BlockerUpdate
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	if u.Description != nil {
+		blockers[i].Description = *u.Description
+	}
+	if u.Resolved != nil {
+		blockers[i].Resolved = *u.Resolved
+	}
+	blockers[i].UpdatedAt = now()
+	writeJSON(w, http.StatusOK, blockers[i])
+}
+
+func (s *Server) listEpics(w http.ResponseWriter, r *http.Request) {
+	p := s.project(w, r)
+	if p == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, append([]api.Epic{}, p.epics...))
+}
+
+func (s *Server) getEpic(w http.ResponseWriter, r *http.Request) {
+	p := s.project(w, r)
+	if p == nil {
+		return
+	}
+	i := slices.IndexFunc(p.epics, func(e api.Epic) bool { return e.ID == pathInt(r, "eid") })
+	if i < 0 {
+		notFound(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, p.epics[i])
+}
+
+func (s *Server) createEpic(w http.ResponseWriter, r *http.Request) {
+	p := s.project(w, r)
+	if p == nil {
+		return
+	}
+	var params struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Label       struct {
+			Name string `json:"name"`
+		} `json:"label"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil || params.Name == "" {
+		badRequest(w, "name is required")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.addEpic(p, params.Name, params.Description, params.Label.Name))
+}
+
+func (s *Server) listIterations(w http.ResponseWriter, r *http.Request) {
+	p := s.project(w, r)
+	if p == nil {
+		return
+	}
+	var kinds []string
+	switch scope := r.URL.Query().Get("scope"); scope {
+	case "":
+	case "current_backlog":
+		kinds = []string{"current", "backlog"}
+	default:
+		kinds = []string{scope}
+	}
+
+	out := []api.Iteration{}
+	for _, it := range p.iterations {
+		if kinds != nil && !slices.Contains(kinds, it.Kind) {
+			continue
+		}
+		// Stories are listed in the project's priority order, so moves
+		// are reflected here too.
+		rendered := it.Iteration
+		rendered.Stories = []api.Story{}
+		for _, st := range p.stories {
+			if slices.Contains(it.storyIDs, st.ID) {
+				rendered.Stories = append(rendered.Stories, s.render(p, st))
+			}
+		}
+		out = append(out, rendered)
+	}
+	page(w, r, out, 10)
+}
+
+func (s *Server) listMemberships(w http.ResponseWriter, r *http.Request) {
+	p := s.project(w, r)
+	if p == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, append([]api.Membership{}, p.memberships...))
+}
+
+func (s *Server) listActivity(w http.ResponseWriter, r *http.Request) {
+	p := s.project(w, r)
+	if p == nil {
+		return
+	}
+	var after time.Time
+	if v := r.URL.Query().Get("occurred_after"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			badRequest(w, fmt.Sprintf("invalid occurred_after %q", v))
+			return
+		}
+		after = t
+	}
+	out := []api.Activity{}
+	for _, a := range p.activity {
+		if t, err := time.Parse(time.RFC3339, a.OccurredAt); err == nil && t.After(after) {
+			out = append(out, a)
+		}
+	}
+	page(w, r, out, 100)
+}
@@ -0,0 +1,270 @@
+package fake
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+)
+
+// sessionCookie is the name of the Rails session cookie the web app sets.
+const sessionCookie = "_litetracker_session"
+
+func (s *Server) registerWeb(mux *http.ServeMux) {
+	mux.HandleFunc("GET /login", s.loginPage)
+	mux.HandleFunc("POST /login", s.login)
+	mux.HandleFunc("GET /{$}", s.dashboard)
+	mux.HandleFunc("POST /api/v1/stories/{sid}/comments", s.web(s.webCreateComment))
+	mux.HandleFunc("PUT /api/v1/comments/{cid}", s.web(s.webUpdateComment))
+	mux.HandleFunc("DELETE /api/v1/comments/{cid}", s.web(s.webDeleteComment))
+	mux.HandleFunc("POST /api/v1/stories/{sid}/labels", s.web(s.webAddLabel))
+	mux.HandleFunc("DELETE /api/v1/stories/{sid}/labels/{lid}", s.web(s.webRemoveLabel))
+	mux.HandleFunc("PUT /api/v1/stories/{sid}", s.web(s.webUpdateStory))
+}
+
+// session returns the session named by the request's cookie, or nil.
+func (s *Server) session(r *http.Request) *session {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+	return s.sessions[c.Value]
+}
+
+// web requires a logged-in session and holds the server lock for the
+// handler. Like the real app it answers 401 once the session is gone.
+func (s *Server) web(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if sess := s.session(r); sess == nil || !sess.loggedIn {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "You need to sign in or sign up before continuing."})
+			return
+		}
+		h(w, r)
+	}
+}
+
+func (s *Server) loginPage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := s.session(r)
+	if sess == nil {
+		sess = &session{}
+		id := rand.Text()
+		s.sessions[id] = sess
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: id, Path: "/", HttpOnly: true})
+	}
+	// Signed-in users are sent on to the app, as the real login page does
+	if sess.loggedIn {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+	sess.csrf = rand.Text()
+	writeLoginForm(w, http.StatusOK, sess.csrf, `<input type="password" name="user[password]" />`)
+}
+
+// writeLoginForm renders a login page step with a fresh CSRF token.
+func writeLoginForm(w http.ResponseWriter, status int, csrf, fields string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head>
+<meta name="csrf-param" content="authenticity_token" />
+<meta name="csrf-token" content="%s" />
+</head><body><form action="/login" method="post">%s</form></body></html>
+`, csrf, fields)
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := s.session(r)
+	if sess == nil || sess.csrf == "" || r.PostFormValue("authenticity_token") != sess.csrf {
+		http.Error(w, "Can't verify CSRF token authenticity.", http.StatusUnprocessableEntity)
+		return
+	}
+	if sess.otpPending {
+		// Like real servers, allow the previous step for clock drift
+		now, _ := api.TOTP(s.totp, time.Now())
+		prev, _ := api.TOTP(s.totp, time.Now().Add(-30*time.Second))
+		if code := r.PostFormValue("user[otp_attempt]"); code == "" || (code != now && code != prev) {
+			http.Error(w, "Invalid two-factor code.", http.StatusUnprocessableEntity)
+			return
+		}
+		sess.otpPending = false
+	} else {
+		if r.PostFormValue("user[login]") != Email || r.PostFormValue("user[password]") != Password {
+			http.Error(w, "Invalid Email or password.", http.StatusUnprocessableEntity)
+			return
+		}
+		if s.totp != "" {
+			sess.otpPending = true
+			sess.csrf = rand.Text()
+			writeLoginForm(w, http.StatusOK, sess.csrf, `<input type="text" name="user[otp_attempt]" autocomplete="one-time-code" />`)
+			return
+		}
+	}
+	sess.loggedIn = true
+	s.logins++
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+func (s *Server) dashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintln(w, "<!DOCTYPE html><html><body>Dashboard</body></html>")
+}
+
+// findStory looks a story up by ID alone, as the /api/v1 routes do.
+func (s *Server) findStory(id int) (*project, *api.Story) {
+	for _, p := range s.projects {
+		if st := p.story(id); st != nil {
+			return p, st
+		}
+	}
+	return nil, nil
+}
+
+// findComment looks a comment up by ID alone, returning the story it is on.
+func (s *Server) findComment(id int) (*project, int, int) {
+	for _, p := range s.projects {
+		for storyID, comments := range p.comments {
+			if i := commentIndex(comments, id); i >= 0 {
+				return p, storyID, i
+			}
+		}
+	}
+	return nil, 0, -1
+}
+
+// v1Comment renders a comment the way the /api/v1 JSON:API endpoints do.
+func v1Comment(c api.Comment) map[string]any {
+	return map[string]any{"data": map[string]any{
+		"id":   strconv.Itoa(c.ID),
+		"type": "comments",
+		"attributes": map[string]any{
+			"content":    c.Text,
+			"created-at": c.CreatedAt,
+			"user-id":    c.PersonID,
+		},
+	}}
+}
+
+func (s *Server) webCreateComment(w http.ResponseWriter, r *http.Request) {
+	p, st := s.findStory(pathInt(r, "sid"))
+	if st == nil {
+		notFound(w)
+		return
+	}
+	text := r.FormValue("comment[content]")
+	userID, _ := strconv.Atoi(r.FormValue("comment[user_id]"))
+	if text == "" || userID == 0 {
+		http.Error(w, `{"errors":["content and user_id are required"]}`, http.StatusUnprocessableEntity)
+		return
+	}
+	writeJSON(w, http.StatusCreated, v1Comment(s.addComment(p, st.ID, userID, text)))
+}
+
+func (s *Server) webUpdateComment(w http.ResponseWriter, r *http.Request) {
+	p, storyID, i := s.findComment(pathInt(r, "cid"))
+	if p == nil {
+		notFound(w)
+		return
+	}
+	text := r.FormValue("comment[content]")
+	if text == "" {
+		http.Error(w, `{"errors":["content is required"]}`, http.StatusUnprocessableEntity)
+		return
+	}
+	c := &p.comments[storyID][i]
+	c.Text = text
+	c.UpdatedAt = now()
+	writeJSON(w, http.StatusOK, v1Comment(*c))
+}
+
+func (s *Server) webDeleteComment(w http.ResponseWriter, r *http.Request) {
+	p, storyID, i := s.findComment(pathInt(r, "cid"))
+	if p == nil {
+		notFound(w)
+		return
+	}
+	p.comments[storyID] = slices.Delete(p.comments[storyID], i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) webAddLabel(w http.ResponseWriter, r *http.Request) {
+	p, st := s.findStory(pathInt(r, "sid"))
+	if st == nil {
+		notFound(w)
+		return
+	}
+	var params struct {
+		Label struct {
+			Name string `json:"name"`
+		} `json:"label"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil || strings.TrimSpace(params.Label.Name) == "" {
+		http.Error(w, `{"errors":["name is required"]}`, http.StatusUnprocessableEntity)
+		return
+	}
+	l := s.label(p, params.Label.Name)
+	if !slices.ContainsFunc(st.Labels, func(x api.Label) bool { return x.ID == l.ID }) {
+		st.Labels = append(st.Labels, l)
+		st.UpdatedAt = now()
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"data": map[string]any{
+		"id":         strconv.Itoa(l.ID),
+		"type":       "labels",
+		"attributes": map[string]any{"name": l.Name},
+	}})
+}
+
+func (s *Server) webRemoveLabel(w http.ResponseWriter, r *http.Request) {
+	_, st := s.findStory(pathInt(r, "sid"))
+	if st == nil {
+		notFound(w)
+		return
+	}
+	i := slices.IndexFunc(st.Labels, func(l api.Label) bool { return l.ID == pathInt(r, "lid") })
+	if i < 0 {
+		notFound(w)
+		return
+	}
+	st.Labels = slices.Delete(st.Labels, i, i+1)
+	st.UpdatedAt = now()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) webUpdateStory(w http.ResponseWriter, r *http.Request) {
+	p, st := s.findStory(pathInt(r, "sid"))
+	if st == nil {
+		notFound(w)
+		return
+	}
+	var params struct {
+		Story struct {
+			OwnerIDs *[]int `json:"owner_ids"`
+		} `json:"story"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		http.Error(w, `{"errors":["invalid JSON"]}`, http.StatusBadRequest)
+		return
+	}
+	if ids := params.Story.OwnerIDs; ids != nil {
+		for _, id := range *ids {
+			if _, ok := s.people[id]; !ok {
+				http.Error(w, fmt.Sprintf(`{"errors":["unknown owner %d"]}`, id), http.StatusUnprocessableEntity)
+				return
+			}
+		}
+		st.OwnerIDs = slices.Clone(*ids)
+		st.UpdatedAt = now()
+	}
+	writeJSON(w, http.StatusOK, s.render(p, st))
+}
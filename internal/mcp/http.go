@@ -0,0 +1,196 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// shutdownTimeout bounds how long in-flight tool calls get to finish once
+// the HTTP server is asked to stop.
+const shutdownTimeout = 10 * time.Second
+
+// ListenAndServe serves s over HTTP on addr until ctx is cancelled, then
+// shuts down gracefully. Streamable HTTP clients connect to /mcp and SSE
+// clients to /sse; every request must carry one of tokens as a bearer token.
+func ListenAndServe(ctx context.Context, s *Server, addr string, tokens []string) error {
+	if len(tokens) == 0 {
+		return errors.New("at least one bearer token is required to serve over HTTP")
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	slog.Info("MCP server listening", "addr", ln.Addr().String())
+	return serveHTTP(ctx, ln, s, tokens)
+}
+
+func serveHTTP(ctx context.Context, ln net.Listener, s *Server, tokens []string) error {
+	// Listening streams never finish on their own, so they are ended when
+	// shutdown starts; tool calls in flight are left to complete.
+	streams, stopStreams := context.WithCancel(context.Background())
+	defer stopStreams()
+	srv := &http.Server{
+		Handler:           newHTTPHandler(s, tokens, streams),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	srv.RegisterOnShutdown(stopStreams)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("MCP server shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// newHTTPHandler mounts the streamable HTTP and SSE transports behind bearer
+// token auth. Each client gets its own MCP session: streamable HTTP clients
+// are tracked by the Mcp-Session-Id header, SSE clients by their stream.
+func newHTTPHandler(s *Server, tokens []string, streams context.Context) http.Handler {
+	mux := http.NewServeMux()
+	streamable := server.NewStreamableHTTPServer(s.MCPServer, server.WithStateful(true))
+	mux.Handle("/mcp", s.interceptSubscribe(s.unregisterOnDelete(streamable),
+		func(r *http.Request) string { return r.Header.Get(server.HeaderKeySessionID) },
+		func(w http.ResponseWriter, _ *http.Request, resp mcp.JSONRPCMessage) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(resp)
+		},
+	))
+	sse := server.NewSSEServer(s.MCPServer)
+	mux.Handle("/sse", sse)
+	mux.Handle("/message", s.interceptSubscribe(sse,
+		func(r *http.Request) string { return r.URL.Query().Get("sessionId") },
+		func(w http.ResponseWriter, r *http.Request, resp mcp.JSONRPCMessage) {
+			// SSE clients get responses on their event stream
+			if err := sse.SendEventToSession(r.URL.Query().Get("sessionId"), resp); err != nil {
+				http.Error(w, "Invalid session ID", http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		},
+	))
+	return requireBearer(tokens, endStreams(streams, mux))
+}
+
+// interceptSubscribe answers subscribe requests posted to a transport with
+// reply, and passes every other request on to next. sessionID extracts the
+// MCP session a request belongs to.
+func (s *Server) interceptSubscribe(next http.Handler, sessionID func(*http.Request) string, reply func(http.ResponseWriter, *http.Request, mcp.JSONRPCMessage)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := sessionID(r)
+		if r.Method != http.MethodPost || id == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "read request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp, ok, err := s.subs.handleMessage(id, body)
+		if errors.Is(err, errSessionNotFound) {
+			http.Error(w, "Session not found", http.StatusNotFound)
+			return
+		}
+		if ok {
+			reply(w, r, resp)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// unregisterOnDelete unregisters a streamable HTTP session once its client
+// ends it with a DELETE. mcp-go only marks the ID terminated, which would
+// leave the session, and any subscriptions it made, registered for good.
+func (s *Server) unregisterOnDelete(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(server.HeaderKeySessionID)
+		if r.Method != http.MethodDelete || id == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.status == http.StatusOK {
+			s.UnregisterSession(r.Context(), id)
+		}
+	})
+}
+
+// statusRecorder remembers the status code written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// requireBearer rejects requests without one of tokens in their
+// Authorization header.
+func requireBearer(tokens []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !validToken(tokens, got) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="litetracker"`)
+			http.Error(w, "missing or invalid bearer token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func validToken(tokens []string, got string) bool {
+	valid := false
+	for _, t := range tokens {
+		// Compare against every token so timing doesn't reveal which matched
+		if t != "" && subtle.ConstantTimeCompare([]byte(t), []byte(got)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// endStreams cancels GET requests, which hold the SSE and streamable HTTP
+// listening streams open, once streams is done.
+func endStreams(streams context.Context, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		stop := context.AfterFunc(streams, cancel)
+		defer stop()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+	"github.com/MelianLabs/litetracker-mcp/internal/fake"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+const testBearer = "team-secret"
+
+func TestHTTPTransports(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	web := httptest.NewServer(newHTTPHandler(NewServer(api.New(srv.Options()), Options{}), []string{"other", testBearer}, context.Background()))
+	defer web.Close()
+	auth := map[string]string{"Authorization": "Bearer " + testBearer}
+
+	for _, tt := range []struct {
+		name string
+		dial func() (*client.Client, error)
+	}{
+		{"streamable", func() (*client.Client, error) {
+			return client.NewStreamableHttpClient(web.URL+"/mcp", transport.WithHTTPHeaders(auth))
+		}},
+		{"sse", func() (*client.Client, error) {
+			return client.NewSSEMCPClient(web.URL+"/sse", transport.WithHeaders(auth))
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// Two clients share the server, each in its own session
+			var sessions []string
+			for range 2 {
+				c, err := tt.dial()
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer c.Close()
+				initialize(t, c)
+				me := decodeResult[api.Me](t, call(t, c, "get_me", nil))
+				if me.ID != fake.UserID {
+					t.Errorf("get_me id = %d, want %d", me.ID, fake.UserID)
+				}
+				sessions = append(sessions, c.GetSessionId())
+			}
+			// The SSE client doesn't expose its session id; its session is its stream
+			if tt.name == "streamable" && (sessions[0] == "" || sessions[0] == sessions[1]) {
+				t.Errorf("session ids = %q, want two distinct ids", sessions)
+			}
+		})
+	}
+}
+
+func TestHTTPRequiresBearerToken(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	web := httptest.NewServer(newHTTPHandler(NewServer(api.New(srv.Options()), Options{}), []string{testBearer}, context.Background()))
+	defer web.Close()
+
+	for _, header := range []string{"", "Bearer wrong", "Bearer ", testBearer} {
+		req, _ := http.NewRequest("GET", web.URL+"/sse", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status = %d, want 401", header, resp.StatusCode)
+		}
+	}
+}
+
+func TestHTTPGracefulShutdown(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serveHTTP(ctx, ln, NewServer(api.New(srv.Options()), Options{}), []string{testBearer}) }()
+
+	// An open SSE stream must not hold up the shutdown
+	c, err := client.NewSSEMCPClient("http://"+ln.Addr().String()+"/sse",
+		transport.WithHeaders(map[string]string{"Authorization": "Bearer " + testBearer}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	initialize(t, c)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serveHTTP = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
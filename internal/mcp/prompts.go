@@ -0,0 +1,271 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// registerPrompts adds the team's common workflows as prompts. Each one
+// fetches the data it needs up front and embeds it in the prompt, so every
+// client starts the workflow from the same context.
+func registerPrompts(s *server.MCPServer, h *handlers) {
+	s.AddPrompt(mcp.NewPrompt("standup",
+		mcp.WithPromptDescription("Draft my daily standup update from the stories I own and what I did in the last day"),
+		mcp.WithArgument("project_id",
+			mcp.ArgumentDescription("Limit the standup to one project (default: all my projects)"),
+		),
+	), h.promptStandup)
+
+	s.AddPrompt(mcp.NewPrompt("triage_bugs",
+		mcp.WithPromptDescription("Triage the unstarted bugs in a project"),
+		mcp.WithArgument("project_id",
+			mcp.ArgumentDescription("Project ID"),
+			mcp.RequiredArgument(),
+		),
+	), h.promptTriageBugs)
+
+	s.AddPrompt(mcp.NewPrompt("break_down_story",
+		mcp.WithPromptDescription("Break a story into tasks"),
+		mcp.WithArgument("project_id",
+			mcp.ArgumentDescription("Project ID"),
+			mcp.RequiredArgument(),
+		),
+		mcp.WithArgument("story_id",
+			mcp.ArgumentDescription("Story ID"),
+			mcp.RequiredArgument(),
+		),
+	), h.promptBreakDownStory)
+
+	s.AddPrompt(mcp.NewPrompt("release_notes",
+		mcp.WithPromptDescription("Draft release notes from the stories accepted since a date"),
+		mcp.WithArgument("project_id",
+			mcp.ArgumentDescription("Project ID"),
+			mcp.RequiredArgument(),
+		),
+		mcp.WithArgument("since",
+			mcp.ArgumentDescription("Include stories accepted on or after this date (YYYY-MM-DD)"),
+			mcp.RequiredArgument(),
+		),
+	), h.promptReleaseNotes)
+}
+
+// inProgressStates are the states of stories someone is working on.
+var inProgressStates = []string{"started", "finished", "delivered", "rejected"}
+
+func (h *handlers) promptStandup(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	me, err := h.api.GetMe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var projectIDs []int
+	if req.Params.Arguments["project_id"] != "" {
+		pid, err := promptInt(req, "project_id")
+		if err != nil {
+			return nil, err
+		}
+		projectIDs = []int{pid}
+	} else {
+		for _, p := range me.Projects {
+			projectIDs = append(projectIDs, p.ProjectID)
+		}
+	}
+
+	since := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Write my (%s's) daily standup update: what I did since yesterday, what I'm doing today, and anything blocking me. "+
+		"Base it only on the LiteTracker data below, keep it to a few short bullets per section, and mention story IDs.\n", me.Name)
+	for _, pid := range projectIDs {
+		project, err := h.api.GetProject(ctx, pid)
+		if err != nil {
+			return nil, err
+		}
+		stories, err := h.api.ListStories(ctx, pid, api.ListStoriesOpts{OwnedBy: me.ID})
+		if err != nil {
+			return nil, err
+		}
+		activities, err := h.api.GetProjectActivity(ctx, pid, since)
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(&b, "\n## %s (project %d)\n\n### My stories in progress\n\n", project.Title, pid)
+		n := 0
+		for _, s := range stories {
+			if slices.Contains(inProgressStates, s.CurrentState) {
+				fmt.Fprintf(&b, "- #%d %s [%s, %s%s]\n", s.ID, s.Title, s.StoryType, s.CurrentState, estimateText(s.Estimate))
+				n++
+			}
+		}
+		if n == 0 {
+			b.WriteString("None.\n")
+		}
+
+		b.WriteString("\n### My activity in the last 24 hours\n\n")
+		n = 0
+		for _, a := range activities {
+			if a.PerformedBy.ID == me.ID {
+				fmt.Fprintf(&b, "- %s (%s)\n", a.Message, a.OccurredAt)
+				n++
+			}
+		}
+		if n == 0 {
+			b.WriteString("None.\n")
+		}
+	}
+	return promptResult("Daily standup for "+me.Name, b.String()), nil
+}
+
+func (h *handlers) promptTriageBugs(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	projectID, err := promptInt(req, "project_id")
+	if err != nil {
+		return nil, err
+	}
+	stories, err := h.api.ListStories(ctx, projectID, api.ListStoriesOpts{State: "unstarted", Filter: "type:bug"})
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Triage the unstarted bugs in project %d below. For each one, suggest a priority, any labels it is missing, "+
+		"whether it needs more information from the reporter, and whether it duplicates another bug. "+
+		"Finish with the order you would work on them in. Don't change any story until I confirm.\n\n", projectID)
+	for _, s := range stories {
+		fmt.Fprintf(&b, "## #%d %s\n\n", s.ID, s.Title)
+		if s.StoryPriority != "" {
+			fmt.Fprintf(&b, "- Priority: %s\n", s.StoryPriority)
+		}
+		if labels := labelNames(s); len(labels) > 0 {
+			fmt.Fprintf(&b, "- Labels: %s\n", strings.Join(labels, ", "))
+		}
+		fmt.Fprintf(&b, "- Created: %s\n- URL: %s\n", s.CreatedAt, s.URL)
+		if s.Description != "" {
+			fmt.Fprintf(&b, "\n%s\n", s.Description)
+		}
+		b.WriteString("\n")
+	}
+	if len(stories) == 0 {
+		b.WriteString("There are no unstarted bugs.\n")
+	}
+	return promptResult(fmt.Sprintf("Triage unstarted bugs in project %d", projectID), b.String()), nil
+}
+
+func (h *handlers) promptBreakDownStory(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	projectID, err := promptInt(req, "project_id")
+	if err != nil {
+		return nil, err
+	}
+	storyID, err := promptInt(req, "story_id")
+	if err != nil {
+		return nil, err
+	}
+	story, err := h.api.GetStory(ctx, projectID, storyID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := h.api.ListTasks(ctx, projectID, storyID)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := h.api.GetStoryComments(ctx, projectID, storyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("Break the story below into small, concrete tasks that together complete it, each doable in under a day. " +
+		"Don't repeat existing tasks. ")
+	if h.readOnly {
+		// add_task isn't available in read-only mode
+		b.WriteString("List the tasks for me to review and add to the story myself.\n\n")
+	} else {
+		b.WriteString("List the tasks for me to review, then add the ones I approve with the add_task tool.\n\n")
+	}
+	fmt.Fprintf(&b, "# #%d %s\n\n- Type: %s\n- State: %s\n", story.ID, story.Title, story.StoryType, story.CurrentState)
+	if story.Estimate != nil {
+		fmt.Fprintf(&b, "- Estimate: %d points\n", *story.Estimate)
+	}
+	if story.Description != "" {
+		fmt.Fprintf(&b, "\n## Description\n\n%s\n", story.Description)
+	}
+	b.WriteString("\n## Existing tasks\n\n")
+	if len(tasks) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, t := range tasks {
+		check := " "
+		if t.Complete {
+			check = "x"
+		}
+		fmt.Fprintf(&b, "- [%s] %s\n", check, t.Description)
+	}
+	if len(comments) > 0 {
+		b.WriteString("\n## Comments\n\n")
+		writeComments(&b, comments)
+	}
+	return promptResult(fmt.Sprintf("Break story #%d into tasks", storyID), b.String()), nil
+}
+
+func (h *handlers) promptReleaseNotes(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	projectID, err := promptInt(req, "project_id")
+	if err != nil {
+		return nil, err
+	}
+	since, err := time.Parse(time.DateOnly, req.Params.Arguments["since"])
+	if err != nil {
+		return nil, fmt.Errorf("since must be a date like 2006-01-02")
+	}
+	// Filtering on acceptance date server-side keeps this to the stories
+	// wanted, however long the project's history
+	stories, err := h.api.ListStories(ctx, projectID, api.ListStoriesOpts{
+		State:  "accepted",
+		Filter: "accepted_since:" + since.Format("01/02/2006"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Draft release notes for project %d from the stories accepted since %s below. "+
+		"Group them into new features, bug fixes and other changes, write each entry for end users rather than developers, "+
+		"and leave out chores that users won't notice.\n\n", projectID, since.Format(time.DateOnly))
+	for _, s := range stories {
+		fmt.Fprintf(&b, "## #%d %s [%s]\n\n", s.ID, s.Title, s.StoryType)
+		if labels := labelNames(s); len(labels) > 0 {
+			fmt.Fprintf(&b, "- Labels: %s\n", strings.Join(labels, ", "))
+		}
+		if s.AcceptedAt != "" {
+			fmt.Fprintf(&b, "- Accepted: %s\n", s.AcceptedAt)
+		}
+		if s.Description != "" {
+			fmt.Fprintf(&b, "\n%s\n", s.Description)
+		}
+		b.WriteString("\n")
+	}
+	if len(stories) == 0 {
+		b.WriteString("No stories have been accepted since then.\n")
+	}
+	return promptResult(fmt.Sprintf("Release notes for project %d since %s", projectID, since.Format(time.DateOnly)), b.String()), nil
+}
+
+func promptResult(description, text string) *mcp.GetPromptResult {
+	return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	})
+}
+
+func promptInt(req mcp.GetPromptRequest, key string) (int, error) {
+	n, err := strconv.Atoi(req.Params.Arguments[key])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", key)
+	}
+	return n, nil
+}
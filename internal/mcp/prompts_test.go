@@ -0,0 +1,165 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+	"github.com/MelianLabs/litetracker-mcp/internal/fake"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// getPrompt fetches a prompt and returns the text of its only message.
+func getPrompt(t *testing.T, c *client.Client, name string, args map[string]string) string {
+	t.Helper()
+	req := mcp.GetPromptRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	res, err := c.GetPrompt(context.Background(), req)
+	if err != nil {
+		t.Fatalf("get prompt %s: %v", name, err)
+	}
+	if len(res.Messages) != 1 {
+		t.Fatalf("prompt %s: %d messages, want 1", name, len(res.Messages))
+	}
+	text, ok := res.Messages[0].Content.(mcp.TextContent)
+	if !ok {
+		t.Fatalf("prompt %s: content is %T", name, res.Messages[0].Content)
+	}
+	return text.Text
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func assertContains(t *testing.T, text string, want, notWant []string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(text, w) {
+			t.Errorf("prompt missing %q:\n%s", w, text)
+		}
+	}
+	for _, w := range notWant {
+		if strings.Contains(text, w) {
+			t.Errorf("prompt contains %q:\n%s", w, text)
+		}
+	}
+}
+
+func TestListPrompts(t *testing.T) {
+	_, c := newTestClient(t)
+	res, err := c.ListPrompts(context.Background(), mcp.ListPromptsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range res.Prompts {
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, ","); got != "break_down_story,release_notes,standup,triage_bugs" {
+		t.Errorf("prompts = %s", got)
+	}
+}
+
+func TestStandupPrompt(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	mine := srv.AddStory(pid, api.Story{Title: "Checkout", CurrentState: "started", OwnerIDs: []int{fake.UserID}})
+	srv.AddStory(pid, api.Story{Title: "Old work", CurrentState: "accepted", OwnerIDs: []int{fake.UserID}})
+	srv.AddStory(pid, api.Story{Title: "Someone else's", CurrentState: "started"})
+	other := srv.AddPerson("Other Dev", "OD")
+	srv.AddActivity(pid, api.Activity{Message: "Dev User finished Login", PerformedBy: api.Person{ID: fake.UserID}, OccurredAt: time.Now().UTC().Format(time.RFC3339)})
+	srv.AddActivity(pid, api.Activity{Message: "Other Dev started Search", PerformedBy: other, OccurredAt: time.Now().UTC().Format(time.RFC3339)})
+
+	text := getPrompt(t, c, "standup", nil)
+	assertContains(t, text,
+		[]string{fake.UserName, "## Web", fmt.Sprintf("- #%d Checkout [feature, started]", mine.ID), "Dev User finished Login"},
+		[]string{"Old work", "Someone else's", "Other Dev started Search"})
+}
+
+func TestTriageBugsPrompt(t *testing.T) {
+	var filters []string
+	srv, c := newTestClient(t, func(o *api.Options) {
+		o.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if strings.HasSuffix(req.URL.Path, "/stories") {
+				filters = append(filters, req.URL.Query().Get("filter"))
+			}
+			return http.DefaultTransport.RoundTrip(req)
+		})
+	})
+	pid := srv.AddProject("Web")
+	srv.AddStory(pid, api.Story{Title: "Crash on save", StoryType: "bug", CurrentState: "unstarted", Description: "Stack trace attached", Labels: []api.Label{{Name: "editor"}}})
+	srv.AddStory(pid, api.Story{Title: "Fixed already", StoryType: "bug", CurrentState: "accepted"})
+	srv.AddStory(pid, api.Story{Title: "New feature", StoryType: "feature", CurrentState: "unstarted"})
+
+	text := getPrompt(t, c, "triage_bugs", map[string]string{"project_id": fmt.Sprint(pid)})
+	assertContains(t, text,
+		[]string{"Crash on save", "Stack trace attached", "- Labels: editor"},
+		[]string{"Fixed already", "New feature"})
+	// Bugs are picked out by the server, not after fetching every story
+	if len(filters) != 1 || filters[0] != "type:bug" {
+		t.Errorf("story list filters = %q, want [type:bug]", filters)
+	}
+
+	req := mcp.GetPromptRequest{}
+	req.Params.Name = "triage_bugs"
+	if _, err := c.GetPrompt(context.Background(), req); err == nil {
+		t.Error("triage_bugs without project_id: want error")
+	}
+}
+
+func TestBreakDownStoryPrompt(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	st := srv.AddStory(pid, api.Story{Title: "Checkout", Description: "Pay with card"})
+	srv.AddTask(pid, st.ID, "Design form", true)
+	srv.AddComment(pid, st.ID, fake.UserID, "Support Apple Pay too")
+
+	text := getPrompt(t, c, "break_down_story", map[string]string{"project_id": fmt.Sprint(pid), "story_id": fmt.Sprint(st.ID)})
+	assertContains(t, text,
+		[]string{"# #" + fmt.Sprint(st.ID) + " Checkout", "Pay with card", "- [x] Design form", "Support Apple Pay too", "add_task"},
+		nil)
+
+	// A read-only server has no add_task tool to point at
+	ro, err := client.NewInProcessClient(NewServer(api.New(srv.Options()), Options{ReadOnly: true}).MCPServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+	initialize(t, ro)
+	text = getPrompt(t, ro, "break_down_story", map[string]string{"project_id": fmt.Sprint(pid), "story_id": fmt.Sprint(st.ID)})
+	assertContains(t, text, []string{"- [x] Design form", "add to the story myself"}, []string{"add_task"})
+}
+
+func TestReleaseNotesPrompt(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	srv.AddStory(pid, api.Story{Title: "Dark mode", CurrentState: "accepted"})
+	srv.AddStory(pid, api.Story{Title: "Still in review", CurrentState: "delivered"})
+	// Accepted long ago and edited since: not part of this release
+	srv.AddStory(pid, api.Story{Title: "Old login page", CurrentState: "accepted", AcceptedAt: "15 Jan 2020, 10:00AM"})
+	args := func(since time.Time) map[string]string {
+		return map[string]string{"project_id": fmt.Sprint(pid), "since": since.Format(time.DateOnly)}
+	}
+
+	text := getPrompt(t, c, "release_notes", args(time.Now().AddDate(0, 0, -7)))
+	assertContains(t, text, []string{"Dark mode [feature]", "- Accepted: "}, []string{"Still in review", "Old login page"})
+
+	text = getPrompt(t, c, "release_notes", args(time.Now().AddDate(0, 0, 2)))
+	assertContains(t, text, []string{"No stories have been accepted since then."}, []string{"Dark mode"})
+
+	req := mcp.GetPromptRequest{}
+	req.Params.Name = "release_notes"
+	req.Params.Arguments = map[string]string{"project_id": fmt.Sprint(pid), "since": "last week"}
+	if _, err := c.GetPrompt(context.Background(), req); err == nil {
+		t.Error("release_notes with an invalid date: want error")
+	}
+}
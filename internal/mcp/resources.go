@@ -0,0 +1,235 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Resource URI templates. Appending ?format=json to a URI returns JSON
+// instead of markdown.
+var (
+	projectTemplate = mcp.NewResourceTemplate("litetracker://projects/{project_id}{?format}", "Project",
+		mcp.WithTemplateDescription("A LiteTracker project and the stories in its current iteration. Markdown by default; add ?format=json for JSON."),
+		mcp.WithTemplateMIMEType("text/markdown"),
+	)
+	storyTemplate = mcp.NewResourceTemplate("litetracker://projects/{project_id}/stories/{story_id}{?format}", "Story",
+		mcp.WithTemplateDescription("A LiteTracker story with its description, tasks and comments. Markdown by default; add ?format=json for JSON."),
+		mcp.WithTemplateMIMEType("text/markdown"),
+	)
+	commentsTemplate = mcp.NewResourceTemplate("litetracker://projects/{project_id}/stories/{story_id}/comments{?format}", "Story comments",
+		mcp.WithTemplateDescription("The comment thread on a LiteTracker story. Markdown by default; add ?format=json for JSON."),
+		mcp.WithTemplateMIMEType("text/markdown"),
+	)
+)
+
+// registerResources exposes projects, stories and story comments as
+// resources, so clients can attach them to a conversation as context
+// without a tool call.
+func registerResources(s *server.MCPServer, h *handlers) {
+	s.AddResourceTemplate(projectTemplate, h.readProject)
+	s.AddResourceTemplate(storyTemplate, h.readStory)
+	s.AddResourceTemplate(commentsTemplate, h.readComments)
+}
+
+// projectResource is the JSON form of the project resource.
+type projectResource struct {
+	ID               int              `json:"id"`
+	Name             string           `json:"name"`
+	Description      string           `json:"description"`
+	CurrentIteration *iterationDetail `json:"current_iteration"`
+}
+
+func (h *handlers) readProject(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	projectID, err := resourceInt(req, "project_id")
+	if err != nil {
+		return nil, err
+	}
+	project, err := h.api.GetProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	iterations, err := h.api.ListIterations(ctx, projectID, "current", 0, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	out := projectResource{ID: project.ID, Name: project.Title, Description: project.Description}
+	if len(iterations) > 0 {
+		it := newIterationDetail(iterations[0])
+		out.CurrentIteration = &it
+	}
+	return renderResource(req, out, func(b *strings.Builder) {
+		fmt.Fprintf(b, "# %s\n\n", out.Name)
+		if out.Description != "" {
+			fmt.Fprintf(b, "%s\n\n", out.Description)
+		}
+		it := out.CurrentIteration
+		if it == nil {
+			b.WriteString("No current iteration.\n")
+			return
+		}
+		fmt.Fprintf(b, "## Current iteration %d (%s – %s)\n\n", it.Number, it.Start, it.Finish)
+		fmt.Fprintf(b, "%d of %d points accepted.\n\n", it.Points.Accepted, it.Points.Total)
+		for _, s := range it.Stories {
+			fmt.Fprintf(b, "- #%d %s [%s, %s%s]", s.ID, s.Name, s.Type, s.State, estimateText(s.Estimate))
+			if len(s.Owners) > 0 {
+				fmt.Fprintf(b, " — %s", strings.Join(s.Owners, ", "))
+			}
+			b.WriteString("\n")
+		}
+	})
+}
+
+func (h *handlers) readStory(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	projectID, storyID, err := resourceStoryIDs(req)
+	if err != nil {
+		return nil, err
+	}
+	story, err := h.api.GetStory(ctx, projectID, storyID)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := h.api.GetStoryComments(ctx, projectID, storyID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := h.api.ListTasks(ctx, projectID, storyID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := newStoryDetail(story, comments, tasks)
+	return renderResource(req, out, func(b *strings.Builder) {
+		fmt.Fprintf(b, "# #%d %s\n\n", out.ID, out.Name)
+		fmt.Fprintf(b, "- Type: %s\n- State: %s\n", out.Type, out.State)
+		if out.Estimate != nil {
+			fmt.Fprintf(b, "- Estimate: %d points\n", *out.Estimate)
+		}
+		if len(out.Labels) > 0 {
+			fmt.Fprintf(b, "- Labels: %s\n", strings.Join(out.Labels, ", "))
+		}
+		if owners := ownerNames(story); len(owners) > 0 {
+			fmt.Fprintf(b, "- Owners: %s\n", strings.Join(owners, ", "))
+		}
+		fmt.Fprintf(b, "- URL: %s\n", out.URL)
+		if out.Description != "" {
+			fmt.Fprintf(b, "\n## Description\n\n%s\n", out.Description)
+		}
+		if len(out.Tasks) > 0 {
+			b.WriteString("\n## Tasks\n\n")
+			for _, t := range out.Tasks {
+				check := " "
+				if t.Complete {
+					check = "x"
+				}
+				fmt.Fprintf(b, "- [%s] %s\n", check, t.Description)
+			}
+		}
+		if len(comments) > 0 {
+			b.WriteString("\n## Comments\n\n")
+			writeComments(b, comments)
+		}
+	})
+}
+
+func (h *handlers) readComments(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	projectID, storyID, err := resourceStoryIDs(req)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := h.api.GetStoryComments(ctx, projectID, storyID)
+	if err != nil {
+		return nil, err
+	}
+	return renderResource(req, summarizeComments(comments), func(b *strings.Builder) {
+		fmt.Fprintf(b, "# Comments on story #%d\n\n", storyID)
+		if len(comments) == 0 {
+			b.WriteString("No comments.\n")
+			return
+		}
+		writeComments(b, comments)
+	})
+}
+
+// writeComments renders a comment thread as markdown, oldest first.
+func writeComments(b *strings.Builder, comments []api.Comment) {
+	for i, c := range comments {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		author := fmt.Sprintf("Person %d", c.PersonID)
+		if c.Person != nil && c.Person.Name != "" {
+			author = c.Person.Name
+		}
+		fmt.Fprintf(b, "**%s** (%s):\n\n%s\n", author, c.CreatedAt, c.Text)
+	}
+}
+
+func estimateText(estimate *int) string {
+	if estimate == nil {
+		return ""
+	}
+	return fmt.Sprintf(", %d pts", *estimate)
+}
+
+// renderResource returns v as JSON if the URI asks for ?format=json, and
+// otherwise the markdown written by md.
+func renderResource(req mcp.ReadResourceRequest, v any, md func(*strings.Builder)) ([]mcp.ResourceContents, error) {
+	switch format := resourceArg(req, "format"); format {
+	case "", "markdown":
+		var b strings.Builder
+		md(&b)
+		return []mcp.ResourceContents{mcp.TextResourceContents{
+			URI: req.Params.URI, MIMEType: "text/markdown", Text: b.String(),
+		}}, nil
+	case "json":
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("marshal: %w", err)
+		}
+		return []mcp.ResourceContents{mcp.TextResourceContents{
+			URI: req.Params.URI, MIMEType: "application/json", Text: string(data),
+		}}, nil
+	default:
+		return nil, fmt.Errorf("invalid format %q: must be markdown or json", format)
+	}
+}
+
+// resourceArg returns a variable matched from the resource URI template.
+func resourceArg(req mcp.ReadResourceRequest, key string) string {
+	switch v := req.Params.Arguments[key].(type) {
+	case []string:
+		if len(v) > 0 {
+			return v[0]
+		}
+	case string:
+		return v
+	}
+	return ""
+}
+
+func resourceInt(req mcp.ReadResourceRequest, key string) (int, error) {
+	n, err := strconv.Atoi(resourceArg(req, key))
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s in %s", key, req.Params.URI)
+	}
+	return n, nil
+}
+
+func resourceStoryIDs(req mcp.ReadResourceRequest) (projectID, storyID int, err error) {
+	if projectID, err = resourceInt(req, "project_id"); err != nil {
+		return 0, 0, err
+	}
+	if storyID, err = resourceInt(req, "story_id"); err != nil {
+		return 0, 0, err
+	}
+	return projectID, storyID, nil
+}
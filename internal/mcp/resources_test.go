@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+	"github.com/MelianLabs/litetracker-mcp/internal/fake"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// readResource reads uri and returns its only text content and MIME type.
+func readResource(t *testing.T, c *client.Client, uri string) (string, string) {
+	t.Helper()
+	req := mcp.ReadResourceRequest{}
+	req.Params.URI = uri
+	res, err := c.ReadResource(context.Background(), req)
+	if err != nil {
+		t.Fatalf("read %s: %v", uri, err)
+	}
+	if len(res.Contents) != 1 {
+		t.Fatalf("read %s: %d contents, want 1", uri, len(res.Contents))
+	}
+	text, ok := res.Contents[0].(mcp.TextResourceContents)
+	if !ok {
+		t.Fatalf("read %s: contents are %T", uri, res.Contents[0])
+	}
+	return text.Text, text.MIMEType
+}
+
+func TestStoryResource(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	est := 3
+	st := srv.AddStory(pid, api.Story{Title: "Checkout", Description: "Pay with card", CurrentState: "started", Estimate: &est, OwnerIDs: []int{fake.UserID}})
+	srv.AddTask(pid, st.ID, "Write tests", true)
+	srv.AddComment(pid, st.ID, fake.UserID, "Looks good")
+
+	uri := fmt.Sprintf("litetracker://projects/%d/stories/%d", pid, st.ID)
+	md, mime := readResource(t, c, uri)
+	if mime != "text/markdown" {
+		t.Errorf("MIME type = %s, want text/markdown", mime)
+	}
+	for _, want := range []string{"# #" + fmt.Sprint(st.ID) + " Checkout", "- State: started", "- Estimate: 3 points", "- Owners: " + fake.UserName, "Pay with card", "- [x] Write tests", "Looks good"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+
+	text, mime := readResource(t, c, uri+"?format=json")
+	if mime != "application/json" {
+		t.Errorf("MIME type = %s, want application/json", mime)
+	}
+	got := decodeResult[storyDetail](t, text)
+	if got.ID != st.ID || len(got.Tasks) != 1 || len(got.Comments) != 1 {
+		t.Errorf("story JSON = %+v", got)
+	}
+
+	comments, _ := readResource(t, c, uri+"/comments?format=json")
+	if cs := decodeResult[[]commentSummary](t, comments); len(cs) != 1 || cs[0].Text != "Looks good" {
+		t.Errorf("comments JSON = %+v", cs)
+	}
+}
+
+func TestProjectResource(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	st := srv.AddStory(pid, api.Story{Title: "Checkout", CurrentState: "started"})
+	srv.AddIteration(pid, "current", time.Now().Add(-24*time.Hour), time.Now().Add(6*24*time.Hour), 10, st.ID)
+
+	md, _ := readResource(t, c, fmt.Sprintf("litetracker://projects/%d", pid))
+	for _, want := range []string{"# Web", "## Current iteration", fmt.Sprintf("- #%d Checkout [feature, started]", st.ID)} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestResourceErrors(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+
+	for _, uri := range []string{
+		fmt.Sprintf("litetracker://projects/%d/stories/999", pid),
+		fmt.Sprintf("litetracker://projects/%d?format=yaml", pid),
+	} {
+		req := mcp.ReadResourceRequest{}
+		req.Params.URI = uri
+		if _, err := c.ReadResource(context.Background(), req); err == nil {
+			t.Errorf("read %s: want error", uri)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -14,24 +15,76 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 )
 
-func NewServer() *server.MCPServer {
+// handlers holds the dependencies shared by the tool handlers.
+type handlers struct {
+	api *api.Client
+	// readOnly is set when the tools that change LiteTracker data are
+	// left out, so prompts don't point at them.
+	readOnly bool
+}
+
+// Server is the LiteTracker MCP server. It adds resource subscriptions,
+// which mcp-go doesn't implement, to the mcp-go server it embeds; serve it
+// with ServeStdio or ListenAndServe so subscribe requests reach them.
+type Server struct {
+	*server.MCPServer
+	subs *subscriptions
+}
+
+// Options configures NewServer.
+type Options struct {
+	// ReadOnly leaves out every tool that changes LiteTracker data, so the
+	// server can be handed to agents that should only look.
+	ReadOnly bool
+}
+
+func NewServer(client *api.Client, opts Options) *Server {
+	h := &handlers{api: client, readOnly: opts.ReadOnly}
+	hooks := &server.Hooks{}
 	s := server.NewMCPServer("litetracker", "2.0.0",
 		server.WithToolCapabilities(false),
+		server.WithResourceCapabilities(true, false),
+		server.WithPromptCapabilities(false),
+		server.WithHooks(hooks),
 	)
+	subs := newSubscriptions(client, s)
+	hooks.AddOnRegisterSession(func(_ context.Context, session server.ClientSession) {
+		subs.addSession(session.SessionID())
+	})
+	hooks.AddOnUnregisterSession(func(_ context.Context, session server.ClientSession) {
+		subs.dropSession(session.SessionID())
+	})
+	addTool := func(tool mcp.Tool, handler server.ToolHandlerFunc) {
+		// Anything not annotated as read-only counts as a write, so a new
+		// tool stays hidden in read-only mode until it is marked safe
+		if opts.ReadOnly && (tool.Annotations.ReadOnlyHint == nil || !*tool.Annotations.ReadOnlyHint) {
+			return
+		}
+		s.AddTool(tool, handler)
+	}
 
-	s.AddTool(mcp.NewTool("get_me",
+	addTool(mcp.NewTool("get_me",
 		mcp.WithDescription("Get current authenticated user info"),
 		mcp.WithTitleAnnotation("My Profile"),
-	), handleGetMe)
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+	), h.handleGetMe)
 
-	s.AddTool(mcp.NewTool("list_projects",
+	addTool(mcp.NewTool("list_projects",
 		mcp.WithDescription("List all LiteTracker projects"),
 		mcp.WithTitleAnnotation("List Projects"),
-	), handleListProjects)
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+	), h.handleListProjects)
 
-	s.AddTool(mcp.NewTool("list_stories",
+	addTool(mcp.NewTool("list_stories",
 		mcp.WithDescription("List stories in a LiteTracker project"),
 		mcp.WithTitleAnnotation("List Stories"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
 		mcp.WithNumber("project_id",
 			mcp.Description("LiteTracker project ID"),
 			mcp.Required(),
@@ -57,11 +110,17 @@ func NewServer() *server.MCPServer {
 		mcp.WithNumber("limit",
 			mcp.Description("Max stories to return (default 20)"),
 		),
-	), handleListStories)
+		mcp.WithNumber("offset",
+			mcp.Description("Number of stories to skip, for fetching the next page"),
+		),
+	), h.handleListStories)
 
-	s.AddTool(mcp.NewTool("get_story",
-		mcp.WithDescription("Get a single story with its comments"),
+	addTool(mcp.NewTool("get_story",
+		mcp.WithDescription("Get a single story with its comments and tasks"),
 		mcp.WithTitleAnnotation("Show Story"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
 		mcp.WithNumber("project_id",
 			mcp.Description("LiteTracker project ID"),
 			mcp.Required(),
@@ -70,11 +129,44 @@ func NewServer() *server.MCPServer {
 			mcp.Description("Story ID"),
 			mcp.Required(),
 		),
-	), handleGetStory)
+	), h.handleGetStory)
+
+	addTool(mcp.NewTool("move_story",
+		mcp.WithDescription("Reprioritize a story: place it directly before or after another story, or at the top or bottom of a panel"),
+		mcp.WithTitleAnnotation("Move Story"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("ID of the story to move"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("before_id",
+			mcp.Description("Place the story directly before (above) this story"),
+		),
+		mcp.WithNumber("after_id",
+			mcp.Description("Place the story directly after (below) this story"),
+		),
+		mcp.WithString("position",
+			mcp.Description("Move to the top or bottom of panel instead of relative to a story"),
+			mcp.Enum("top", "bottom"),
+		),
+		mcp.WithString("panel",
+			mcp.Description("Panel for position: current, backlog, or icebox"),
+			mcp.Enum(api.StoryPanels...),
+		),
+	), h.handleMoveStory)
 
-	s.AddTool(mcp.NewTool("get_story_comments",
+	addTool(mcp.NewTool("get_story_comments",
 		mcp.WithDescription("Get comments for a story"),
 		mcp.WithTitleAnnotation("Show Comments"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
 		mcp.WithNumber("project_id",
 			mcp.Description("LiteTracker project ID"),
 			mcp.Required(),
@@ -83,11 +175,14 @@ func NewServer() *server.MCPServer {
 			mcp.Description("Story ID"),
 			mcp.Required(),
 		),
-	), handleGetStoryComments)
+	), h.handleGetStoryComments)
 
-	s.AddTool(mcp.NewTool("post_comment",
+	addTool(mcp.NewTool("post_comment",
 		mcp.WithDescription("Post a comment on a story"),
 		mcp.WithTitleAnnotation("Post Comment"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
 		mcp.WithNumber("project_id",
 			mcp.Description("LiteTracker project ID"),
 			mcp.Required(),
@@ -100,11 +195,247 @@ func NewServer() *server.MCPServer {
 			mcp.Description("Comment text to post"),
 			mcp.Required(),
 		),
-	), handlePostComment)
+	), h.handlePostComment)
+
+	addTool(mcp.NewTool("edit_comment",
+		mcp.WithDescription("Edit the text of one of your comments on a story"),
+		mcp.WithTitleAnnotation("Edit Comment"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("Story ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("comment_id",
+			mcp.Description("Comment ID"),
+			mcp.Required(),
+		),
+		mcp.WithString("text",
+			mcp.Description("New comment text"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("allow_others",
+			mcp.Description("Allow editing a comment written by someone else (default false)"),
+		),
+	), h.handleEditComment)
+
+	addTool(mcp.NewTool("delete_comment",
+		mcp.WithDescription("Delete one of your comments on a story"),
+		mcp.WithTitleAnnotation("Delete Comment"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("Story ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("comment_id",
+			mcp.Description("Comment ID"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("allow_others",
+			mcp.Description("Allow deleting a comment written by someone else (default false)"),
+		),
+	), h.handleDeleteComment)
+
+	addTool(mcp.NewTool("list_tasks",
+		mcp.WithDescription("List the tasks (checklist items) on a story in order"),
+		mcp.WithTitleAnnotation("List Tasks"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("Story ID"),
+			mcp.Required(),
+		),
+	), h.handleListTasks)
+
+	addTool(mcp.NewTool("add_task",
+		mcp.WithDescription("Add a task (checklist item) to a story"),
+		mcp.WithTitleAnnotation("Add Task"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("Story ID"),
+			mcp.Required(),
+		),
+		mcp.WithString("description",
+			mcp.Description("Task description"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("position",
+			mcp.Description("1-based position in the checklist (default: append to the end)"),
+		),
+	), h.handleAddTask)
+
+	addTool(mcp.NewTool("complete_task",
+		mcp.WithDescription("Mark a story task as done, or as not done with complete=false"),
+		mcp.WithTitleAnnotation("Complete Task"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("Story ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("task_id",
+			mcp.Description("Task ID"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("complete",
+			mcp.Description("Whether the task is done (default true)"),
+		),
+	), h.handleCompleteTask)
+
+	addTool(mcp.NewTool("reorder_task",
+		mcp.WithDescription("Move a story task to a new position in the checklist"),
+		mcp.WithTitleAnnotation("Reorder Task"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("Story ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("task_id",
+			mcp.Description("Task ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("position",
+			mcp.Description("New 1-based position in the checklist"),
+			mcp.Required(),
+		),
+	), h.handleReorderTask)
+
+	addTool(mcp.NewTool("delete_task",
+		mcp.WithDescription("Delete a task from a story"),
+		mcp.WithTitleAnnotation("Delete Task"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("Story ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("task_id",
+			mcp.Description("Task ID"),
+			mcp.Required(),
+		),
+	), h.handleDeleteTask)
+
+	addTool(mcp.NewTool("list_blockers",
+		mcp.WithDescription("List the blockers on a story, including resolved ones"),
+		mcp.WithTitleAnnotation("List Blockers"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("Story ID"),
+			mcp.Required(),
+		),
+	), h.handleListBlockers)
+
+	addTool(mcp.NewTool("add_blocker",
+		mcp.WithDescription("Mark a story as blocked, either by another story or by a free-form reason"),
+		mcp.WithTitleAnnotation("Add Blocker"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("ID of the story that is blocked"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("blocking_story_id",
+			mcp.Description("ID of the story it is blocked by. Recorded as \"#<id>\" in the blocker description."),
+		),
+		mcp.WithString("description",
+			mcp.Description("Reason for the blocker. Required if blocking_story_id is not given."),
+		),
+	), h.handleAddBlocker)
+
+	addTool(mcp.NewTool("resolve_blocker",
+		mcp.WithDescription("Mark a story's blocker as resolved"),
+		mcp.WithTitleAnnotation("Resolve Blocker"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("Story ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("blocker_id",
+			mcp.Description("Blocker ID (from list_blockers)"),
+			mcp.Required(),
+		),
+	), h.handleResolveBlocker)
+
+	addTool(mcp.NewTool("get_dependency_graph",
+		mcp.WithDescription("Follow a story's unresolved blockers transitively and return the full blocking chain, to explain why a story is stuck"),
+		mcp.WithTitleAnnotation("Dependency Graph"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("Story ID to start from"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("max_depth",
+			mcp.Description("How many levels of blockers to follow (default 5)"),
+		),
+	), h.handleGetDependencyGraph)
 
-	s.AddTool(mcp.NewTool("create_story",
+	addTool(mcp.NewTool("create_story",
 		mcp.WithDescription("Create a new story in a LiteTracker project"),
 		mcp.WithTitleAnnotation("Create Story"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
 		mcp.WithNumber("project_id",
 			mcp.Description("LiteTracker project ID"),
 			mcp.Required(),
@@ -125,11 +456,191 @@ func NewServer() *server.MCPServer {
 		mcp.WithString("labels",
 			mcp.Description("Comma-separated label names"),
 		),
-	), handleCreateStory)
+	), h.handleCreateStory)
+
+	addTool(mcp.NewTool("update_story",
+		mcp.WithDescription("Update a story's title, description, type, estimate or priority. Only the given fields are changed; returns a before/after diff."),
+		mcp.WithTitleAnnotation("Update Story"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("Story ID"),
+			mcp.Required(),
+		),
+		mcp.WithString("title",
+			mcp.Description("New story title"),
+		),
+		mcp.WithString("description",
+			mcp.Description("New story description/body"),
+		),
+		mcp.WithString("story_type",
+			mcp.Description("New story type: feature, bug, or chore"),
+		),
+		mcp.WithNumber("estimate",
+			mcp.Description("New point estimate, or null to remove the estimate"),
+		),
+		mcp.WithString("priority",
+			mcp.Description("New story priority"),
+		),
+	), h.handleUpdateStory)
+
+	addTool(mcp.NewTool("update_story_state",
+		mcp.WithDescription("Move a story through its workflow (start, deliver, accept, reject). When rejecting, an optional reason is posted as a comment."),
+		mcp.WithTitleAnnotation("Update Story State"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("Story ID"),
+			mcp.Required(),
+		),
+		mcp.WithString("state",
+			mcp.Description("New state: started, unstarted, delivered, accepted, rejected"),
+			mcp.Enum(api.StoryStates...),
+			mcp.Required(),
+		),
+		mcp.WithString("reason",
+			mcp.Description("Rejection reason, posted as a comment. Only allowed when state is rejected."),
+		),
+	), h.handleUpdateStoryState)
+
+	addTool(mcp.NewTool("list_iterations",
+		mcp.WithDescription("List a project's iterations (sprints) with their dates, velocity and points"),
+		mcp.WithTitleAnnotation("List Iterations"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithString("scope",
+			mcp.Description("Which iterations to list: current, backlog, current_backlog, or done (default: all)"),
+			mcp.Enum(api.IterationScopes...),
+		),
+		mcp.WithNumber("offset",
+			mcp.Description("Number of iterations to skip"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Max iterations to return (default 10)"),
+		),
+	), h.handleListIterations)
+
+	addTool(mcp.NewTool("get_current_iteration",
+		mcp.WithDescription("Get the current iteration with its stories, points, velocity and start/finish dates. Answers \"what's left in this sprint?\""),
+		mcp.WithTitleAnnotation("Current Iteration"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+	), h.handleGetCurrentIteration)
+
+	addTool(mcp.NewTool("list_epics",
+		mcp.WithDescription("List the epics in a LiteTracker project"),
+		mcp.WithTitleAnnotation("List Epics"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+	), h.handleListEpics)
+
+	addTool(mcp.NewTool("get_epic",
+		mcp.WithDescription("Get an epic with its stories and progress, computed from the stories carrying the epic's label"),
+		mcp.WithTitleAnnotation("Show Epic"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("epic_id",
+			mcp.Description("Epic ID"),
+			mcp.Required(),
+		),
+	), h.handleGetEpic)
+
+	addTool(mcp.NewTool("create_epic",
+		mcp.WithDescription("Create a new epic in a LiteTracker project"),
+		mcp.WithTitleAnnotation("Create Epic"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithString("name",
+			mcp.Description("Epic name"),
+			mcp.Required(),
+		),
+		mcp.WithString("description",
+			mcp.Description("Epic description"),
+		),
+		mcp.WithString("label",
+			mcp.Description("Label that groups the epic's stories (default: derived from the name)"),
+		),
+	), h.handleCreateEpic)
+
+	addTool(mcp.NewTool("bulk_update_stories",
+		mcp.WithDescription("Apply one operation to many stories at once (set state, add/remove a label, add/remove an owner, or set an estimate). Returns a per-story success or error report."),
+		mcp.WithTitleAnnotation("Bulk Update Stories"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithArray("story_ids",
+			mcp.Description("IDs of the stories to update"),
+			mcp.WithNumberItems(),
+			mcp.Required(),
+		),
+		mcp.WithString("operation",
+			mcp.Description("Operation to apply: set_state, add_label, remove_label, add_owner, remove_owner, set_estimate"),
+			mcp.Enum(api.BulkOperations...),
+			mcp.Required(),
+		),
+		mcp.WithString("state",
+			mcp.Description("New state for set_state"),
+			mcp.Enum(api.StoryStates...),
+		),
+		mcp.WithString("label",
+			mcp.Description("Label name for add_label and remove_label"),
+		),
+		mcp.WithNumber("user_id",
+			mcp.Description("User ID for add_owner and remove_owner. Optional if name is provided."),
+		),
+		mcp.WithString("name",
+			mcp.Description("Name or initials to resolve to a user ID for add_owner and remove_owner"),
+		),
+		mcp.WithNumber("estimate",
+			mcp.Description("Point estimate for set_estimate"),
+		),
+	), h.handleBulkUpdateStories)
 
-	s.AddTool(mcp.NewTool("get_project_activity",
+	addTool(mcp.NewTool("get_project_activity",
 		mcp.WithDescription("Get recent activity for a project"),
 		mcp.WithTitleAnnotation("Project Activity"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
 		mcp.WithNumber("project_id",
 			mcp.Description("LiteTracker project ID"),
 			mcp.Required(),
@@ -137,11 +648,20 @@ func NewServer() *server.MCPServer {
 		mcp.WithString("occurred_after",
 			mcp.Description("Only show activity after this date (e.g. '2026-02-01T00:00:00Z')"),
 		),
-	), handleGetProjectActivity)
+		mcp.WithNumber("offset",
+			mcp.Description("Number of activities to skip, for fetching the next page"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Max activities to return (default 100)"),
+		),
+	), h.handleGetProjectActivity)
 
-	s.AddTool(mcp.NewTool("find_owner",
+	addTool(mcp.NewTool("find_owner",
 		mcp.WithDescription("Search for a project member by name or initials to find their user ID. Useful before add_owner."),
 		mcp.WithTitleAnnotation("Find Owner"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
 		mcp.WithNumber("project_id",
 			mcp.Description("LiteTracker project ID"),
 			mcp.Required(),
@@ -150,11 +670,14 @@ func NewServer() *server.MCPServer {
 			mcp.Description("Name or initials to search for (case-insensitive)"),
 			mcp.Required(),
 		),
-	), handleFindOwner)
+	), h.handleFindOwner)
 
-	s.AddTool(mcp.NewTool("add_label",
+	addTool(mcp.NewTool("add_label",
 		mcp.WithDescription("Add a label to a story"),
 		mcp.WithTitleAnnotation("Add Label"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
 		mcp.WithNumber("project_id",
 			mcp.Description("LiteTracker project ID"),
 			mcp.Required(),
@@ -167,11 +690,14 @@ func NewServer() *server.MCPServer {
 			mcp.Description("Label name to add"),
 			mcp.Required(),
 		),
-	), handleAddLabel)
+	), h.handleAddLabel)
 
-	s.AddTool(mcp.NewTool("add_owner",
+	addTool(mcp.NewTool("add_owner",
 		mcp.WithDescription("Add an owner to a story. Provide user_id directly, or provide name to auto-resolve via project memberships."),
 		mcp.WithTitleAnnotation("Add Owner"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
 		mcp.WithNumber("project_id",
 			mcp.Description("LiteTracker project ID"),
 			mcp.Required(),
@@ -186,15 +712,65 @@ func NewServer() *server.MCPServer {
 		mcp.WithString("name",
 			mcp.Description("Name or initials to resolve to a user ID (case-insensitive). Used when user_id is not provided."),
 		),
-	), handleAddOwner)
+	), h.handleAddOwner)
 
-	return s
-}
+	addTool(mcp.NewTool("remove_label",
+		mcp.WithDescription("Remove a label from a story. Fails if the story doesn't have the label."),
+		mcp.WithTitleAnnotation("Remove Label"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("Story ID"),
+			mcp.Required(),
+		),
+		mcp.WithString("label",
+			mcp.Description("Label name to remove (case-insensitive)"),
+			mcp.Required(),
+		),
+	), h.handleRemoveLabel)
 
-func textResult(v any) (*mcp.CallToolResult, error) {
-	b, err := json.MarshalIndent(v, "", "  ")
-	if err != nil {
-		return nil, fmt.Errorf("marshal: %w", err)
+	addTool(mcp.NewTool("remove_owner",
+		mcp.WithDescription("Remove an owner from a story. Provide user_id directly, or provide name to auto-resolve via project memberships. Fails if the user isn't an owner."),
+		mcp.WithTitleAnnotation("Remove Owner"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithNumber("project_id",
+			mcp.Description("LiteTracker project ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("story_id",
+			mcp.Description("Story ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("user_id",
+			mcp.Description("User ID to remove as owner. Optional if name is provided."),
+		),
+		mcp.WithString("name",
+			mcp.Description("Name or initials to resolve to a user ID (case-insensitive). Used when user_id is not provided."),
+		),
+	), h.handleRemoveOwner)
+
+	registerResources(s, h)
+	registerPrompts(s, h)
+	return &Server{MCPServer: s, subs: subs}
+}
+
+// WatchSubscriptions polls project activity every interval until ctx is
+// cancelled, notifying clients subscribed to a resource that changed.
+func (s *Server) WatchSubscriptions(ctx context.Context, interval time.Duration) {
+	s.subs.watch(ctx, interval)
+}
+
+func textResult(v any) (*mcp.CallToolResult, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
 	}
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
@@ -204,14 +780,42 @@ func textResult(v any) (*mcp.CallToolResult, error) {
 }
 
 func errResult(err error) (*mcp.CallToolResult, error) {
+	text := fmt.Sprintf("Error: %v", err)
+	if hint := errHint(err); hint != "" {
+		text += "\n" + hint
+	}
 	return &mcp.CallToolResult{
 		IsError: true,
 		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: fmt.Sprintf("Error: %v", err)},
+			mcp.TextContent{Type: "text", Text: text},
 		},
 	}, nil
 }
 
+// errHint suggests what the model can do about a LiteTracker API error.
+func errHint(err error) string {
+	var apiErr *api.APIError
+	resource := "the requested resource"
+	if errors.As(err, &apiErr) && apiErr.Resource != "" {
+		resource = apiErr.Resource
+	}
+	switch {
+	case errors.Is(err, api.ErrNotFound):
+		return fmt.Sprintf("Hint: %s was not found. Check the IDs, e.g. with list_projects or list_stories.", resource)
+	case errors.Is(err, api.ErrSessionExpired):
+		return "Hint: the imported browser session has expired. Ask the user to run `litetracker login` again; read-only tools still work."
+	case errors.Is(err, api.ErrUnauthorized):
+		return "Hint: LiteTracker rejected the credentials. Check LITETRACKER_TOKEN, or LITETRACKER_EMAIL and LITETRACKER_PASSWORD for write tools."
+	case errors.Is(err, api.ErrForbidden):
+		return fmt.Sprintf("Hint: the configured user does not have permission to access %s.", resource)
+	case errors.Is(err, api.ErrRateLimited):
+		return "Hint: LiteTracker is rate limiting requests. Wait a minute before retrying."
+	case apiErr != nil && apiErr.Retryable:
+		return "Hint: this looks like a temporary LiteTracker outage. Retrying later may succeed."
+	}
+	return ""
+}
+
 func getInt(req mcp.CallToolRequest, key string) int {
 	args := req.GetArguments()
 	v, ok := args[key]
@@ -231,250 +835,1122 @@ func getInt(req mcp.CallToolRequest, key string) int {
 	}
 }
 
+func getBool(req mcp.CallToolRequest, key string) bool {
+	args := req.GetArguments()
+	v, ok := args[key]
+	if !ok {
+		return false
+	}
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		parsed, _ := strconv.ParseBool(b)
+		return parsed
+	default:
+		return false
+	}
+}
+
+// getIntSlice accepts either a JSON array of numbers or a comma-separated
+// string.
+func getIntSlice(req mcp.CallToolRequest, key string) []int {
+	args := req.GetArguments()
+	v, ok := args[key]
+	if !ok {
+		return nil
+	}
+	var out []int
+	switch items := v.(type) {
+	case []any:
+		for _, item := range items {
+			switch n := item.(type) {
+			case float64:
+				out = append(out, int(n))
+			case string:
+				if i, err := strconv.Atoi(strings.TrimSpace(n)); err == nil {
+					out = append(out, i)
+				}
+			}
+		}
+	case string:
+		for _, part := range strings.Split(items, ",") {
+			if i, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+				out = append(out, i)
+			}
+		}
+	}
+	return out
+}
+
+func hasArg(req mcp.CallToolRequest, key string) bool {
+	_, ok := req.GetArguments()[key]
+	return ok
+}
+
 func getString(req mcp.CallToolRequest, key string) string {
 	args := req.GetArguments()
 	v, ok := args[key]
 	if !ok {
 		return ""
 	}
-	s, _ := v.(string)
-	return s
+	s, _ := v.(string)
+	return s
+}
+
+func (h *handlers) handleListProjects(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projects, err := h.api.ListProjects(ctx)
+	if err != nil {
+		return errResult(err)
+	}
+	type summary struct {
+		ID          int    `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	out := make([]summary, len(projects))
+	for i, p := range projects {
+		out[i] = summary{ID: p.ID, Name: p.Title, Description: p.Description}
+	}
+	return textResult(out)
+}
+
+func (h *handlers) handleListStories(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	if projectID == 0 {
+		return errResult(fmt.Errorf("project_id is required"))
+	}
+	opts := api.ListStoriesOpts{
+		Filter:      getString(req, "filter"),
+		Query:       getInt(req, "query"),
+		Owners:      getInt(req, "owners"),
+		SectionType: getString(req, "section_type"),
+		OwnedBy:     getInt(req, "owned_by"),
+		State:       getString(req, "state"),
+		Limit:       getInt(req, "limit"),
+		Offset:      getInt(req, "offset"),
+	}
+	stories, page, err := h.api.ListStoriesPage(ctx, projectID, opts)
+	if err != nil {
+		return errResult(err)
+	}
+	type summary struct {
+		ID       int      `json:"id"`
+		Name     string   `json:"name"`
+		Type     string   `json:"type"`
+		State    string   `json:"state"`
+		Labels   []string `json:"labels"`
+		Estimate *int     `json:"estimate"`
+		URL      string   `json:"url"`
+	}
+	out := make([]summary, len(stories))
+	for i, s := range stories {
+		out[i] = summary{
+			ID: s.ID, Name: s.Title, Type: s.StoryType,
+			State: s.CurrentState, Labels: labelNames(s), Estimate: s.Estimate, URL: s.URL,
+		}
+	}
+	type result struct {
+		Stories []summary `json:"stories"`
+		pageInfo
+	}
+	return textResult(result{Stories: out, pageInfo: newPageInfo(page)})
+}
+
+// pageInfo tells the model where a page sits in the full result set and
+// which offset fetches the next one.
+type pageInfo struct {
+	Offset     int  `json:"offset"`
+	Total      *int `json:"total,omitempty"`
+	HasMore    bool `json:"has_more"`
+	NextOffset *int `json:"next_offset,omitempty"`
+}
+
+func newPageInfo(p api.Page) pageInfo {
+	info := pageInfo{Offset: p.Offset, HasMore: p.HasMore()}
+	if p.Total >= 0 {
+		total := p.Total
+		info.Total = &total
+	}
+	if info.HasMore {
+		next := p.Offset + p.Returned
+		info.NextOffset = &next
+	}
+	return info
+}
+
+func (h *handlers) handleGetStory(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	if projectID == 0 || storyID == 0 {
+		return errResult(fmt.Errorf("project_id and story_id are required"))
+	}
+
+	story, err := h.api.GetStory(ctx, projectID, storyID)
+	if err != nil {
+		return errResult(err)
+	}
+	comments, err := h.api.GetStoryComments(ctx, projectID, storyID)
+	if err != nil {
+		return errResult(err)
+	}
+	tasks, err := h.api.ListTasks(ctx, projectID, storyID)
+	if err != nil {
+		return errResult(err)
+	}
+
+	return textResult(newStoryDetail(story, comments, tasks))
+}
+
+// storyDetail is a story with its comments and tasks, as get_story and the
+// story resource return it.
+type storyDetail struct {
+	ID          int              `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Type        string           `json:"type"`
+	State       string           `json:"state"`
+	Labels      []string         `json:"labels"`
+	Estimate    *int             `json:"estimate"`
+	OwnerIDs    []int            `json:"owner_ids"`
+	URL         string           `json:"url"`
+	CreatedAt   string           `json:"created_at"`
+	UpdatedAt   string           `json:"updated_at"`
+	Comments    []commentSummary `json:"comments"`
+	Tasks       []taskSummary    `json:"tasks"`
+}
+
+func newStoryDetail(story api.Story, comments []api.Comment, tasks []api.Task) storyDetail {
+	return storyDetail{
+		ID: story.ID, Name: story.Title, Description: story.Description,
+		Type: story.StoryType, State: story.CurrentState, Labels: labelNames(story),
+		Estimate: story.Estimate, OwnerIDs: story.OwnerIDs, URL: story.URL,
+		CreatedAt: story.CreatedAt, UpdatedAt: story.UpdatedAt,
+		Comments: summarizeComments(comments), Tasks: summarizeTasks(tasks),
+	}
+}
+
+type commentSummary struct {
+	ID        int    `json:"id"`
+	Text      string `json:"text"`
+	PersonID  int    `json:"person_id"`
+	CreatedAt string `json:"created_at"`
+}
+
+func summarizeComments(comments []api.Comment) []commentSummary {
+	out := make([]commentSummary, len(comments))
+	for i, c := range comments {
+		out[i] = commentSummary{ID: c.ID, Text: c.Text, PersonID: c.PersonID, CreatedAt: c.CreatedAt}
+	}
+	return out
+}
+
+type taskSummary struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+	Complete    bool   `json:"complete"`
+	Position    int    `json:"position"`
+}
+
+func summarizeTask(t api.Task) taskSummary {
+	return taskSummary{ID: t.ID, Description: t.Description, Complete: t.Complete, Position: t.Position}
+}
+
+func summarizeTasks(tasks []api.Task) []taskSummary {
+	out := make([]taskSummary, len(tasks))
+	for i, t := range tasks {
+		out[i] = summarizeTask(t)
+	}
+	return out
+}
+
+func (h *handlers) handleListTasks(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	if projectID == 0 || storyID == 0 {
+		return errResult(fmt.Errorf("project_id and story_id are required"))
+	}
+
+	tasks, err := h.api.ListTasks(ctx, projectID, storyID)
+	if err != nil {
+		return errResult(err)
+	}
+	return textResult(summarizeTasks(tasks))
+}
+
+func (h *handlers) handleAddTask(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	description := getString(req, "description")
+	if projectID == 0 || storyID == 0 || description == "" {
+		return errResult(fmt.Errorf("project_id, story_id, and description are required"))
+	}
+
+	task, err := h.api.CreateTask(ctx, projectID, storyID, description, getInt(req, "position"))
+	if err != nil {
+		return errResult(err)
+	}
+	return textResult(summarizeTask(task))
+}
+
+func (h *handlers) handleCompleteTask(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	taskID := getInt(req, "task_id")
+	if projectID == 0 || storyID == 0 || taskID == 0 {
+		return errResult(fmt.Errorf("project_id, story_id, and task_id are required"))
+	}
+	complete := true
+	if hasArg(req, "complete") {
+		complete = getBool(req, "complete")
+	}
+
+	task, err := h.api.UpdateTask(ctx, projectID, storyID, taskID, api.TaskUpdate{Complete: &complete})
+	if err != nil {
+		return errResult(err)
+	}
+	return textResult(summarizeTask(task))
+}
+
+func (h *handlers) handleReorderTask(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	taskID := getInt(req, "task_id")
+	position := getInt(req, "position")
+	if projectID == 0 || storyID == 0 || taskID == 0 || position < 1 {
+		return errResult(fmt.Errorf("project_id, story_id, task_id, and a position of at least 1 are required"))
+	}
+
+	if _, err := h.api.UpdateTask(ctx, projectID, storyID, taskID, api.TaskUpdate{Position: &position}); err != nil {
+		return errResult(err)
+	}
+	// Positions of the other tasks shift too, so return the whole checklist.
+	tasks, err := h.api.ListTasks(ctx, projectID, storyID)
+	if err != nil {
+		return errResult(err)
+	}
+	return textResult(summarizeTasks(tasks))
+}
+
+func (h *handlers) handleDeleteTask(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	taskID := getInt(req, "task_id")
+	if projectID == 0 || storyID == 0 || taskID == 0 {
+		return errResult(fmt.Errorf("project_id, story_id, and task_id are required"))
+	}
+
+	if err := h.api.DeleteTask(ctx, projectID, storyID, taskID); err != nil {
+		return errResult(err)
+	}
+
+	type result struct {
+		ID      int  `json:"id"`
+		Deleted bool `json:"deleted"`
+	}
+	return textResult(result{ID: taskID, Deleted: true})
+}
+
+type blockerSummary struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+	Resolved    bool   `json:"resolved"`
+	BlockedBy   []int  `json:"blocked_by_story_ids,omitempty"`
+	CreatedAt   string `json:"created_at"`
+}
+
+func summarizeBlocker(b api.Blocker) blockerSummary {
+	return blockerSummary{
+		ID: b.ID, Description: b.Description, Resolved: b.Resolved,
+		BlockedBy: b.BlockingStoryIDs(), CreatedAt: b.CreatedAt,
+	}
+}
+
+func (h *handlers) handleListBlockers(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	if projectID == 0 || storyID == 0 {
+		return errResult(fmt.Errorf("project_id and story_id are required"))
+	}
+
+	blockers, err := h.api.ListBlockers(ctx, projectID, storyID)
+	if err != nil {
+		return errResult(err)
+	}
+	out := make([]blockerSummary, len(blockers))
+	for i, b := range blockers {
+		out[i] = summarizeBlocker(b)
+	}
+	return textResult(out)
+}
+
+func (h *handlers) handleAddBlocker(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	blockingID := getInt(req, "blocking_story_id")
+	description := getString(req, "description")
+	if projectID == 0 || storyID == 0 {
+		return errResult(fmt.Errorf("project_id and story_id are required"))
+	}
+	if blockingID == 0 && description == "" {
+		return errResult(fmt.Errorf("either blocking_story_id or description is required"))
+	}
+	if blockingID == storyID {
+		return errResult(fmt.Errorf("a story cannot block itself"))
+	}
+	if blockingID != 0 {
+		ref := fmt.Sprintf("#%d", blockingID)
+		if description == "" {
+			description = ref
+		} else if !strings.Contains(description, ref) {
+			description = ref + " " + description
+		}
+	}
+
+	blocker, err := h.api.CreateBlocker(ctx, projectID, storyID, description)
+	if err != nil {
+		return errResult(err)
+	}
+	return textResult(summarizeBlocker(blocker))
+}
+
+func (h *handlers) handleResolveBlocker(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	blockerID := getInt(req, "blocker_id")
+	if projectID == 0 || storyID == 0 || blockerID == 0 {
+		return errResult(fmt.Errorf("project_id, story_id, and blocker_id are required"))
+	}
+
+	resolved := true
+	blocker, err := h.api.UpdateBlocker(ctx, projectID, storyID, blockerID, api.BlockerUpdate{Resolved: &resolved})
+	if err != nil {
+		return errResult(err)
+	}
+	return textResult(summarizeBlocker(blocker))
+}
+
+// maxGraphStories bounds how many stories get_dependency_graph will fetch,
+// so a large web of blockers can't turn one tool call into hundreds of
+// API requests.
+const maxGraphStories = 50
+
+func (h *handlers) handleGetDependencyGraph(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	if projectID == 0 || storyID == 0 {
+		return errResult(fmt.Errorf("project_id and story_id are required"))
+	}
+	maxDepth := getInt(req, "max_depth")
+	if maxDepth <= 0 {
+		maxDepth = 5
+	}
+
+	type node struct {
+		ID            int      `json:"id"`
+		Name          string   `json:"name"`
+		State         string   `json:"state"`
+		URL           string   `json:"url"`
+		Depth         int      `json:"depth"`
+		BlockedBy     []int    `json:"blocked_by,omitempty"`
+		OtherBlockers []string `json:"other_blockers,omitempty"`
+		Error         string   `json:"error,omitempty"`
+	}
+	type result struct {
+		RootID    int    `json:"root_id"`
+		Stories   []node `json:"stories"`
+		Truncated bool   `json:"truncated"`
+	}
+
+	out := result{RootID: storyID}
+	depthOf := map[int]int{storyID: 0}
+	queue := []int{storyID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		depth := depthOf[id]
+
+		n := node{ID: id, Depth: depth}
+		story, err := h.api.GetStory(ctx, projectID, id)
+		if err != nil {
+			if id == storyID {
+				return errResult(err)
+			}
+			n.Error = err.Error()
+			out.Stories = append(out.Stories, n)
+			continue
+		}
+		n.Name, n.State, n.URL = story.Title, story.CurrentState, story.URL
+
+		blockers, err := h.api.ListBlockers(ctx, projectID, id)
+		if err != nil {
+			n.Error = err.Error()
+			out.Stories = append(out.Stories, n)
+			continue
+		}
+		for _, b := range blockers {
+			if b.Resolved {
+				continue
+			}
+			refs := b.BlockingStoryIDs()
+			if len(refs) == 0 {
+				n.OtherBlockers = append(n.OtherBlockers, b.Description)
+				continue
+			}
+			for _, ref := range refs {
+				n.BlockedBy = append(n.BlockedBy, ref)
+				if _, seen := depthOf[ref]; seen {
+					continue
+				}
+				if depth+1 > maxDepth || len(depthOf) >= maxGraphStories {
+					out.Truncated = true
+					continue
+				}
+				depthOf[ref] = depth + 1
+				queue = append(queue, ref)
+			}
+		}
+		out.Stories = append(out.Stories, n)
+	}
+	return textResult(out)
+}
+
+func (h *handlers) handleMoveStory(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	beforeID := getInt(req, "before_id")
+	afterID := getInt(req, "after_id")
+	position := getString(req, "position")
+	panel := getString(req, "panel")
+	if projectID == 0 || storyID == 0 {
+		return errResult(fmt.Errorf("project_id and story_id are required"))
+	}
+
+	given := 0
+	for _, set := range []bool{beforeID != 0, afterID != 0, position != ""} {
+		if set {
+			given++
+		}
+	}
+	if given != 1 {
+		return errResult(fmt.Errorf("exactly one of before_id, after_id, or position is required"))
+	}
+
+	if position != "" {
+		if panel == "" {
+			return errResult(fmt.Errorf("panel is required with position"))
+		}
+		stories, err := h.api.ListPanelStories(ctx, projectID, panel)
+		if err != nil {
+			return errResult(err)
+		}
+		others := make([]api.Story, 0, len(stories))
+		for _, s := range stories {
+			if s.ID != storyID {
+				others = append(others, s)
+			}
+		}
+		if len(others) == 0 {
+			return errResult(fmt.Errorf("the %s panel has no other stories to move relative to", panel))
+		}
+		switch position {
+		case "top":
+			beforeID = others[0].ID
+		case "bottom":
+			afterID = others[len(others)-1].ID
+		default:
+			return errResult(fmt.Errorf("invalid position %q: must be top or bottom", position))
+		}
+	}
+
+	story, err := h.api.MoveStory(ctx, projectID, storyID, beforeID, afterID)
+	if err != nil {
+		return errResult(err)
+	}
+
+	type result struct {
+		ID       int    `json:"id"`
+		Name     string `json:"name"`
+		State    string `json:"state"`
+		BeforeID int    `json:"before_id,omitempty"`
+		AfterID  int    `json:"after_id,omitempty"`
+		URL      string `json:"url"`
+	}
+	return textResult(result{
+		ID: story.ID, Name: story.Title, State: story.CurrentState,
+		BeforeID: beforeID, AfterID: afterID, URL: story.URL,
+	})
+}
+
+func (h *handlers) handleGetStoryComments(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	if projectID == 0 || storyID == 0 {
+		return errResult(fmt.Errorf("project_id and story_id are required"))
+	}
+
+	comments, err := h.api.GetStoryComments(ctx, projectID, storyID)
+	if err != nil {
+		return errResult(err)
+	}
+
+	return textResult(summarizeComments(comments))
+}
+
+func (h *handlers) handleCreateStory(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	title := getString(req, "title")
+	if projectID == 0 || title == "" {
+		return errResult(fmt.Errorf("project_id and title are required"))
+	}
+
+	params := map[string]any{"name": title}
+
+	if desc := getString(req, "description"); desc != "" {
+		params["description"] = desc
+	}
+	if st := getString(req, "story_type"); st != "" {
+		params["story_type"] = st
+	}
+	if est := getInt(req, "estimate"); est != 0 {
+		params["estimate"] = est
+	}
+	if labels := getString(req, "labels"); labels != "" {
+		labelList := []map[string]string{}
+		for _, l := range strings.Split(labels, ",") {
+			l = strings.TrimSpace(l)
+			if l != "" {
+				labelList = append(labelList, map[string]string{"name": l})
+			}
+		}
+		params["labels"] = labelList
+	}
+
+	story, err := h.api.CreateStory(ctx, projectID, params)
+	if err != nil {
+		return errResult(err)
+	}
+
+	type result struct {
+		ID    int    `json:"id"`
+		Name  string `json:"name"`
+		Type  string `json:"type"`
+		State string `json:"state"`
+		URL   string `json:"url"`
+	}
+	return textResult(result{
+		ID: story.ID, Name: story.Title, Type: story.StoryType,
+		State: story.CurrentState, URL: story.URL,
+	})
+}
+
+func (h *handlers) handlePostComment(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	text := getString(req, "text")
+	if projectID == 0 || storyID == 0 || text == "" {
+		return errResult(fmt.Errorf("project_id, story_id, and text are required"))
+	}
+
+	comment, err := h.api.WebPostComment(ctx, projectID, storyID, text)
+	if err != nil {
+		return errResult(err)
+	}
+
+	type result struct {
+		ID        int    `json:"id"`
+		Text      string `json:"text"`
+		CreatedAt string `json:"created_at"`
+	}
+	return textResult(result{ID: comment.ID, Text: comment.Text, CreatedAt: comment.CreatedAt})
+}
+
+func (h *handlers) handleEditComment(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	commentID := getInt(req, "comment_id")
+	text := getString(req, "text")
+	if projectID == 0 || storyID == 0 || commentID == 0 || text == "" {
+		return errResult(fmt.Errorf("project_id, story_id, comment_id, and text are required"))
+	}
+
+	comment, err := h.api.WebEditComment(ctx, projectID, storyID, commentID, text, getBool(req, "allow_others"))
+	if err != nil {
+		return errResult(err)
+	}
+
+	type result struct {
+		ID        int    `json:"id"`
+		Text      string `json:"text"`
+		CreatedAt string `json:"created_at"`
+	}
+	return textResult(result{ID: comment.ID, Text: comment.Text, CreatedAt: comment.CreatedAt})
+}
+
+func (h *handlers) handleDeleteComment(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	commentID := getInt(req, "comment_id")
+	if projectID == 0 || storyID == 0 || commentID == 0 {
+		return errResult(fmt.Errorf("project_id, story_id, and comment_id are required"))
+	}
+
+	comment, err := h.api.WebDeleteComment(ctx, projectID, storyID, commentID, getBool(req, "allow_others"))
+	if err != nil {
+		return errResult(err)
+	}
+
+	type result struct {
+		ID      int    `json:"id"`
+		Text    string `json:"text"`
+		Deleted bool   `json:"deleted"`
+	}
+	return textResult(result{ID: comment.ID, Text: comment.Text, Deleted: true})
+}
+
+func (h *handlers) handleUpdateStory(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	if projectID == 0 || storyID == 0 {
+		return errResult(fmt.Errorf("project_id and story_id are required"))
+	}
+
+	var update api.StoryUpdate
+	if hasArg(req, "title") {
+		title := getString(req, "title")
+		if title == "" {
+			return errResult(fmt.Errorf("title cannot be empty"))
+		}
+		update.Title = &title
+	}
+	if hasArg(req, "description") {
+		desc := getString(req, "description")
+		update.Description = &desc
+	}
+	if hasArg(req, "story_type") {
+		st := getString(req, "story_type")
+		update.StoryType = &st
+	}
+	if hasArg(req, "estimate") {
+		if req.GetArguments()["estimate"] == nil {
+			update.ClearEstimate = true
+		} else {
+			est := getInt(req, "estimate")
+			update.Estimate = &est
+		}
+	}
+	if hasArg(req, "priority") {
+		p := getString(req, "priority")
+		update.StoryPriority = &p
+	}
+	if update == (api.StoryUpdate{}) {
+		return errResult(fmt.Errorf("at least one of title, description, story_type, estimate, or priority is required"))
+	}
+
+	before, err := h.api.GetStory(ctx, projectID, storyID)
+	if err != nil {
+		return errResult(err)
+	}
+	after, err := h.api.UpdateStory(ctx, projectID, storyID, update)
+	if err != nil {
+		return errResult(err)
+	}
+
+	type change struct {
+		Before any `json:"before"`
+		After  any `json:"after"`
+	}
+	changes := map[string]change{}
+	diff := func(field string, set bool, b, a any) {
+		if set && b != a {
+			changes[field] = change{Before: b, After: a}
+		}
+	}
+	diff("title", update.Title != nil, before.Title, after.Title)
+	diff("description", update.Description != nil, before.Description, after.Description)
+	diff("story_type", update.StoryType != nil, before.StoryType, after.StoryType)
+	diff("estimate", update.Estimate != nil || update.ClearEstimate, derefInt(before.Estimate), derefInt(after.Estimate))
+	diff("priority", update.StoryPriority != nil, before.StoryPriority, after.StoryPriority)
+
+	type result struct {
+		ID      int               `json:"id"`
+		Name    string            `json:"name"`
+		URL     string            `json:"url"`
+		Changes map[string]change `json:"changes"`
+	}
+	return textResult(result{ID: after.ID, Name: after.Title, URL: after.URL, Changes: changes})
+}
+
+func derefInt(p *int) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+func (h *handlers) handleUpdateStoryState(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	state := getString(req, "state")
+	reason := getString(req, "reason")
+	if projectID == 0 || storyID == 0 || state == "" {
+		return errResult(fmt.Errorf("project_id, story_id, and state are required"))
+	}
+	if reason != "" && state != "rejected" {
+		return errResult(fmt.Errorf("reason can only be given when state is rejected"))
+	}
+
+	story, err := h.api.UpdateStoryState(ctx, projectID, storyID, state)
+	if err != nil {
+		return errResult(err)
+	}
+
+	type result struct {
+		ID                 int    `json:"id"`
+		Name               string `json:"name"`
+		State              string `json:"state"`
+		URL                string `json:"url"`
+		RejectionCommentID int    `json:"rejection_comment_id,omitempty"`
+	}
+	out := result{ID: story.ID, Name: story.Title, State: story.CurrentState, URL: story.URL}
+
+	if reason != "" {
+		comment, err := h.api.WebPostComment(ctx, projectID, storyID, reason)
+		if err != nil {
+			return errResult(fmt.Errorf("story %d was rejected but posting the reason failed: %w", storyID, err))
+		}
+		out.RejectionCommentID = comment.ID
+	}
+	return textResult(out)
 }
 
-func handleListProjects(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	projects, err := api.ListProjects()
+func (h *handlers) handleGetMe(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	me, err := h.api.GetMe(ctx)
 	if err != nil {
 		return errResult(err)
 	}
-	type summary struct {
-		ID          int    `json:"id"`
-		Name        string `json:"name"`
-		Description string `json:"description"`
+	type project struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+		Role string `json:"role"`
 	}
-	out := make([]summary, len(projects))
-	for i, p := range projects {
-		out[i] = summary{ID: p.ID, Name: p.Title, Description: p.Description}
+	type result struct {
+		ID       int       `json:"id"`
+		Name     string    `json:"name"`
+		Username string    `json:"username"`
+		Email    string    `json:"email"`
+		Initials string    `json:"initials"`
+		Projects []project `json:"projects"`
 	}
-	return textResult(out)
+	projects := make([]project, len(me.Projects))
+	for i, p := range me.Projects {
+		projects[i] = project{ID: p.ProjectID, Name: p.ProjectName, Role: p.Role}
+	}
+	return textResult(result{
+		ID: me.ID, Name: me.Name, Username: me.Username,
+		Email: me.Email, Initials: me.Initials, Projects: projects,
+	})
 }
 
-func handleListStories(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+type iterationPoints struct {
+	Total     int `json:"total"`
+	Accepted  int `json:"accepted"`
+	Remaining int `json:"remaining"`
+}
+
+type iterationSummary struct {
+	Number       int             `json:"number"`
+	Kind         string          `json:"kind,omitempty"`
+	Start        string          `json:"start"`
+	Finish       string          `json:"finish"`
+	Velocity     float64         `json:"velocity"`
+	TeamStrength float64         `json:"team_strength"`
+	StoryCount   int             `json:"story_count"`
+	Points       iterationPoints `json:"points"`
+}
+
+func summarizeIteration(it api.Iteration) iterationSummary {
+	var pts iterationPoints
+	for _, s := range it.Stories {
+		if s.Estimate == nil {
+			continue
+		}
+		pts.Total += *s.Estimate
+		if s.CurrentState == "accepted" {
+			pts.Accepted += *s.Estimate
+		}
+	}
+	pts.Remaining = pts.Total - pts.Accepted
+	return iterationSummary{
+		Number: it.Number, Kind: it.Kind, Start: it.Start, Finish: it.Finish,
+		Velocity: it.Velocity, TeamStrength: it.TeamStrength,
+		StoryCount: len(it.Stories), Points: pts,
+	}
+}
+
+func (h *handlers) handleListIterations(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	projectID := getInt(req, "project_id")
 	if projectID == 0 {
 		return errResult(fmt.Errorf("project_id is required"))
 	}
-	opts := api.ListStoriesOpts{
-		Filter:      getString(req, "filter"),
-		Query:       getInt(req, "query"),
-		Owners:      getInt(req, "owners"),
-		SectionType: getString(req, "section_type"),
-		OwnedBy:     getInt(req, "owned_by"),
-		State:       getString(req, "state"),
-		Limit:       getInt(req, "limit"),
+	limit := getInt(req, "limit")
+	if limit == 0 {
+		limit = 10
 	}
-	stories, err := api.ListStories(projectID, opts)
+
+	iterations, err := h.api.ListIterations(ctx, projectID, getString(req, "scope"), getInt(req, "offset"), limit)
 	if err != nil {
 		return errResult(err)
 	}
-	type summary struct {
-		ID       int      `json:"id"`
-		Name     string   `json:"name"`
-		Type     string   `json:"type"`
-		State    string   `json:"state"`
-		Labels   []string `json:"labels"`
-		Estimate *int     `json:"estimate"`
-		URL      string   `json:"url"`
-	}
-	out := make([]summary, len(stories))
-	for i, s := range stories {
-		labels := make([]string, len(s.Labels))
-		for j, l := range s.Labels {
-			labels[j] = l.Name
-		}
-		out[i] = summary{
-			ID: s.ID, Name: s.Title, Type: s.StoryType,
-			State: s.CurrentState, Labels: labels, Estimate: s.Estimate, URL: s.URL,
-		}
+	out := make([]iterationSummary, len(iterations))
+	for i, it := range iterations {
+		out[i] = summarizeIteration(it)
 	}
 	return textResult(out)
 }
 
-func handleGetStory(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (h *handlers) handleGetCurrentIteration(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	projectID := getInt(req, "project_id")
-	storyID := getInt(req, "story_id")
-	if projectID == 0 || storyID == 0 {
-		return errResult(fmt.Errorf("project_id and story_id are required"))
+	if projectID == 0 {
+		return errResult(fmt.Errorf("project_id is required"))
 	}
 
-	story, err := api.GetStory(projectID, storyID)
+	iterations, err := h.api.ListIterations(ctx, projectID, "current", 0, 1)
 	if err != nil {
 		return errResult(err)
 	}
-	comments, err := api.GetStoryComments(projectID, storyID)
-	if err != nil {
-		return errResult(err)
+	if len(iterations) == 0 {
+		return errResult(fmt.Errorf("project %d has no current iteration", projectID))
 	}
+	it := iterations[0]
 
-	type commentSummary struct {
-		ID        int    `json:"id"`
-		Text      string `json:"text"`
-		PersonID  int    `json:"person_id"`
-		CreatedAt string `json:"created_at"`
+	return textResult(newIterationDetail(it))
+}
+
+// iterationDetail is an iteration with its stories, as
+// get_current_iteration and the project resource return it.
+type iterationDetail struct {
+	iterationSummary
+	Stories []iterationStory `json:"stories"`
+}
+
+type iterationStory struct {
+	ID       int      `json:"id"`
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	State    string   `json:"state"`
+	Estimate *int     `json:"estimate"`
+	Owners   []string `json:"owners"`
+	URL      string   `json:"url"`
+}
+
+func newIterationDetail(it api.Iteration) iterationDetail {
+	stories := make([]iterationStory, len(it.Stories))
+	for i, s := range it.Stories {
+		stories[i] = iterationStory{
+			ID: s.ID, Name: s.Title, Type: s.StoryType, State: s.CurrentState,
+			Estimate: s.Estimate, Owners: ownerNames(s), URL: s.URL,
+		}
 	}
-	type result struct {
-		ID          int              `json:"id"`
-		Name        string           `json:"name"`
-		Description string           `json:"description"`
-		Type        string           `json:"type"`
-		State       string           `json:"state"`
-		Labels      []string         `json:"labels"`
-		Estimate    *int             `json:"estimate"`
-		OwnerIDs    []int            `json:"owner_ids"`
-		URL         string           `json:"url"`
-		CreatedAt   string           `json:"created_at"`
-		UpdatedAt   string           `json:"updated_at"`
-		Comments    []commentSummary `json:"comments"`
-	}
-
-	labels := make([]string, len(story.Labels))
-	for i, l := range story.Labels {
+	return iterationDetail{iterationSummary: summarizeIteration(it), Stories: stories}
+}
+
+func labelNames(s api.Story) []string {
+	labels := make([]string, len(s.Labels))
+	for i, l := range s.Labels {
 		labels[i] = l.Name
 	}
-	cs := make([]commentSummary, len(comments))
-	for i, c := range comments {
-		cs[i] = commentSummary{ID: c.ID, Text: c.Text, PersonID: c.PersonID, CreatedAt: c.CreatedAt}
+	return labels
+}
+
+func ownerNames(s api.Story) []string {
+	owners := make([]string, len(s.Owners))
+	for i, o := range s.Owners {
+		owners[i] = o.Name
 	}
+	return owners
+}
 
-	return textResult(result{
-		ID: story.ID, Name: story.Title, Description: story.Description,
-		Type: story.StoryType, State: story.CurrentState, Labels: labels,
-		Estimate: story.Estimate, OwnerIDs: story.OwnerIDs, URL: story.URL,
-		CreatedAt: story.CreatedAt, UpdatedAt: story.UpdatedAt, Comments: cs,
-	})
+type epicSummary struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Label       string `json:"label"`
+	URL         string `json:"url"`
+}
+
+func summarizeEpic(e api.Epic) epicSummary {
+	return epicSummary{ID: e.ID, Name: e.Name, Description: e.Description, Label: e.Label.Name, URL: e.URL}
 }
 
-func handleGetStoryComments(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (h *handlers) handleListEpics(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	projectID := getInt(req, "project_id")
-	storyID := getInt(req, "story_id")
-	if projectID == 0 || storyID == 0 {
-		return errResult(fmt.Errorf("project_id and story_id are required"))
+	if projectID == 0 {
+		return errResult(fmt.Errorf("project_id is required"))
 	}
 
-	comments, err := api.GetStoryComments(projectID, storyID)
+	epics, err := h.api.ListEpics(ctx, projectID)
 	if err != nil {
 		return errResult(err)
 	}
-
-	type summary struct {
-		ID        int    `json:"id"`
-		Text      string `json:"text"`
-		PersonID  int    `json:"person_id"`
-		CreatedAt string `json:"created_at"`
-	}
-	out := make([]summary, len(comments))
-	for i, c := range comments {
-		out[i] = summary{ID: c.ID, Text: c.Text, PersonID: c.PersonID, CreatedAt: c.CreatedAt}
+	out := make([]epicSummary, len(epics))
+	for i, e := range epics {
+		out[i] = summarizeEpic(e)
 	}
 	return textResult(out)
 }
 
-func handleCreateStory(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (h *handlers) handleGetEpic(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	projectID := getInt(req, "project_id")
-	title := getString(req, "title")
-	if projectID == 0 || title == "" {
-		return errResult(fmt.Errorf("project_id and title are required"))
+	epicID := getInt(req, "epic_id")
+	if projectID == 0 || epicID == 0 {
+		return errResult(fmt.Errorf("project_id and epic_id are required"))
 	}
 
-	params := map[string]any{"name": title}
+	epic, err := h.api.GetEpic(ctx, projectID, epicID)
+	if err != nil {
+		return errResult(err)
+	}
 
-	if desc := getString(req, "description"); desc != "" {
-		params["description"] = desc
+	var stories []api.Story
+	if epic.Label.Name != "" {
+		stories, err = h.api.ListStories(ctx, projectID, api.ListStoriesOpts{
+			Filter: fmt.Sprintf("label:%q", epic.Label.Name),
+		})
+		if err != nil {
+			return errResult(err)
+		}
 	}
-	if st := getString(req, "story_type"); st != "" {
-		params["story_type"] = st
+
+	type storySummary struct {
+		ID       int    `json:"id"`
+		Name     string `json:"name"`
+		Type     string `json:"type"`
+		State    string `json:"state"`
+		Estimate *int   `json:"estimate"`
+		URL      string `json:"url"`
 	}
-	if est := getInt(req, "estimate"); est != 0 {
-		params["estimate"] = est
+	type progress struct {
+		TotalStories    int            `json:"total_stories"`
+		AcceptedStories int            `json:"accepted_stories"`
+		ByState         map[string]int `json:"by_state"`
+		TotalPoints     int            `json:"total_points"`
+		AcceptedPoints  int            `json:"accepted_points"`
+		PercentComplete float64        `json:"percent_complete"`
 	}
-	if labels := getString(req, "labels"); labels != "" {
-		labelList := []map[string]string{}
-		for _, l := range strings.Split(labels, ",") {
-			l = strings.TrimSpace(l)
-			if l != "" {
-				labelList = append(labelList, map[string]string{"name": l})
-			}
-		}
-		params["labels"] = labelList
+	type result struct {
+		epicSummary
+		Progress progress       `json:"progress"`
+		Stories  []storySummary `json:"stories"`
 	}
 
-	story, err := api.CreateStory(projectID, params)
-	if err != nil {
-		return errResult(err)
+	p := progress{ByState: map[string]int{}}
+	out := make([]storySummary, len(stories))
+	for i, s := range stories {
+		out[i] = storySummary{
+			ID: s.ID, Name: s.Title, Type: s.StoryType,
+			State: s.CurrentState, Estimate: s.Estimate, URL: s.URL,
+		}
+		p.TotalStories++
+		p.ByState[s.CurrentState]++
+		points := 0
+		if s.Estimate != nil {
+			points = *s.Estimate
+		}
+		p.TotalPoints += points
+		if s.CurrentState == "accepted" {
+			p.AcceptedStories++
+			p.AcceptedPoints += points
+		}
 	}
-
-	type result struct {
-		ID    int    `json:"id"`
-		Name  string `json:"name"`
-		Type  string `json:"type"`
-		State string `json:"state"`
-		URL   string `json:"url"`
+	// Weight by points when the stories are estimated, otherwise by count.
+	switch {
+	case p.TotalPoints > 0:
+		p.PercentComplete = float64(p.AcceptedPoints) * 100 / float64(p.TotalPoints)
+	case p.TotalStories > 0:
+		p.PercentComplete = float64(p.AcceptedStories) * 100 / float64(p.TotalStories)
 	}
-	return textResult(result{
-		ID: story.ID, Name: story.Title, Type: story.StoryType,
-		State: story.CurrentState, URL: story.URL,
-	})
+
+	return textResult(result{epicSummary: summarizeEpic(epic), Progress: p, Stories: out})
 }
 
-func handlePostComment(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (h *handlers) handleCreateEpic(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	projectID := getInt(req, "project_id")
-	storyID := getInt(req, "story_id")
-	text := getString(req, "text")
-	if projectID == 0 || storyID == 0 || text == "" {
-		return errResult(fmt.Errorf("project_id, story_id, and text are required"))
+	name := getString(req, "name")
+	if projectID == 0 || name == "" {
+		return errResult(fmt.Errorf("project_id and name are required"))
 	}
 
-	comment, err := api.WebPostComment(projectID, storyID, text)
+	epic, err := h.api.CreateEpic(ctx, projectID, name, getString(req, "description"), getString(req, "label"))
 	if err != nil {
 		return errResult(err)
 	}
+	return textResult(summarizeEpic(epic))
+}
 
-	type result struct {
-		ID        int    `json:"id"`
-		Text      string `json:"text"`
-		CreatedAt string `json:"created_at"`
+// maxBulkStories caps how many stories one bulk_update_stories call touches.
+const maxBulkStories = 100
+
+func (h *handlers) handleBulkUpdateStories(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyIDs := getIntSlice(req, "story_ids")
+	operation := getString(req, "operation")
+	if projectID == 0 || len(storyIDs) == 0 || operation == "" {
+		return errResult(fmt.Errorf("project_id, story_ids, and operation are required"))
+	}
+	if len(storyIDs) > maxBulkStories {
+		return errResult(fmt.Errorf("at most %d stories can be updated at once, got %d", maxBulkStories, len(storyIDs)))
+	}
+
+	op := api.BulkOperation{Kind: operation}
+	switch operation {
+	case "set_state":
+		op.State = getString(req, "state")
+	case "add_label", "remove_label":
+		op.Label = getString(req, "label")
+	case "add_owner", "remove_owner":
+		ownerID, err := h.ownerFromArgs(ctx, req, projectID)
+		if err != nil {
+			return errResult(err)
+		}
+		op.OwnerID = ownerID
+	case "set_estimate":
+		if !hasArg(req, "estimate") {
+			return errResult(fmt.Errorf("estimate is required for set_estimate"))
+		}
+		op.Estimate = getInt(req, "estimate")
 	}
-	return textResult(result{ID: comment.ID, Text: comment.Text, CreatedAt: comment.CreatedAt})
-}
 
-func handleGetMe(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	me, err := api.GetMe()
+	results, err := h.api.BulkUpdateStories(ctx, projectID, storyIDs, op)
 	if err != nil {
 		return errResult(err)
 	}
-	type project struct {
-		ID   int    `json:"id"`
-		Name string `json:"name"`
-		Role string `json:"role"`
+
+	type itemResult struct {
+		StoryID int    `json:"story_id"`
+		OK      bool   `json:"ok"`
+		Error   string `json:"error,omitempty"`
 	}
 	type result struct {
-		ID       int       `json:"id"`
-		Name     string    `json:"name"`
-		Username string    `json:"username"`
-		Email    string    `json:"email"`
-		Initials string    `json:"initials"`
-		Projects []project `json:"projects"`
+		Operation string       `json:"operation"`
+		Succeeded int          `json:"succeeded"`
+		Failed    int          `json:"failed"`
+		Results   []itemResult `json:"results"`
 	}
-	projects := make([]project, len(me.Projects))
-	for i, p := range me.Projects {
-		projects[i] = project{ID: p.ProjectID, Name: p.ProjectName, Role: p.Role}
+	out := result{Operation: operation, Results: make([]itemResult, len(results))}
+	for i, r := range results {
+		out.Results[i] = itemResult{StoryID: r.StoryID, OK: r.Err == nil}
+		if r.Err != nil {
+			out.Results[i].Error = r.Err.Error()
+			out.Failed++
+		} else {
+			out.Succeeded++
+		}
 	}
-	return textResult(result{
-		ID: me.ID, Name: me.Name, Username: me.Username,
-		Email: me.Email, Initials: me.Initials, Projects: projects,
-	})
+	return textResult(out)
 }
 
-func handleGetProjectActivity(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (h *handlers) handleGetProjectActivity(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	projectID := getInt(req, "project_id")
 	if projectID == 0 {
 		return errResult(fmt.Errorf("project_id is required"))
@@ -484,7 +1960,7 @@ func handleGetProjectActivity(_ context.Context, req mcp.CallToolRequest) (*mcp.
 		occurredAfter = time.Now().AddDate(0, 0, -7).Format(time.RFC3339)
 	}
 
-	activities, err := api.GetProjectActivity(projectID, occurredAfter)
+	activities, page, err := h.api.GetProjectActivityPage(ctx, projectID, occurredAfter, getInt(req, "offset"), getInt(req, "limit"))
 	if err != nil {
 		return errResult(err)
 	}
@@ -494,10 +1970,10 @@ func handleGetProjectActivity(_ context.Context, req mcp.CallToolRequest) (*mcp.
 		URL  string `json:"url"`
 	}
 	type summary struct {
-		Message    string     `json:"message"`
-		PerformedBy string   `json:"performed_by"`
-		OccurredAt string    `json:"occurred_at"`
-		Resources  []resource `json:"resources"`
+		Message     string     `json:"message"`
+		PerformedBy string     `json:"performed_by"`
+		OccurredAt  string     `json:"occurred_at"`
+		Resources   []resource `json:"resources"`
 	}
 	out := make([]summary, len(activities))
 	for i, a := range activities {
@@ -512,11 +1988,15 @@ func handleGetProjectActivity(_ context.Context, req mcp.CallToolRequest) (*mcp.
 			Resources:   resources,
 		}
 	}
-	return textResult(out)
+	type result struct {
+		Activities []summary `json:"activities"`
+		pageInfo
+	}
+	return textResult(result{Activities: out, pageInfo: newPageInfo(page)})
 }
 
-func resolveOwnerID(projectID int, query string) (int, string, error) {
-	memberships, err := api.GetProjectMemberships(projectID)
+func (h *handlers) resolveOwnerID(ctx context.Context, projectID int, query string) (int, string, error) {
+	memberships, err := h.api.GetProjectMemberships(ctx, projectID)
 	if err != nil {
 		return 0, "", err
 	}
@@ -547,14 +2027,14 @@ func resolveOwnerID(projectID int, query string) (int, string, error) {
 	return matches[0].id, matches[0].name, nil
 }
 
-func handleFindOwner(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (h *handlers) handleFindOwner(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	projectID := getInt(req, "project_id")
 	query := getString(req, "query")
 	if projectID == 0 || query == "" {
 		return errResult(fmt.Errorf("project_id and query are required"))
 	}
 
-	memberships, err := api.GetProjectMemberships(projectID)
+	memberships, err := h.api.GetProjectMemberships(ctx, projectID)
 	if err != nil {
 		return errResult(err)
 	}
@@ -585,7 +2065,7 @@ func handleFindOwner(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolR
 	return textResult(matches)
 }
 
-func handleAddLabel(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (h *handlers) handleAddLabel(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	projectID := getInt(req, "project_id")
 	storyID := getInt(req, "story_id")
 	label := getString(req, "label")
@@ -593,7 +2073,7 @@ func handleAddLabel(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolRe
 		return errResult(fmt.Errorf("project_id, story_id, and label are required"))
 	}
 
-	result, err := api.WebAddLabel(projectID, storyID, label)
+	result, err := h.api.WebAddLabel(ctx, projectID, storyID, label)
 	if err != nil {
 		return errResult(err)
 	}
@@ -605,39 +2085,91 @@ func handleAddLabel(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolRe
 	return textResult(labelResult{ID: result.ID, Name: result.Name})
 }
 
-func handleAddOwner(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	projectID := getInt(req, "project_id")
-	storyID := getInt(req, "story_id")
+// ownerFromArgs returns the user_id argument, or resolves the name argument
+// against the project's memberships when user_id is not given.
+func (h *handlers) ownerFromArgs(ctx context.Context, req mcp.CallToolRequest, projectID int) (int, error) {
 	userID := getInt(req, "user_id")
 	name := getString(req, "name")
-	if projectID == 0 || storyID == 0 {
-		return errResult(fmt.Errorf("project_id and story_id are required"))
-	}
 	if userID == 0 && name == "" {
-		return errResult(fmt.Errorf("either user_id or name is required"))
+		return 0, fmt.Errorf("either user_id or name is required")
 	}
 	if userID == 0 {
-		resolved, resolvedName, err := resolveOwnerID(projectID, name)
+		resolved, _, err := h.resolveOwnerID(ctx, projectID, name)
 		if err != nil {
-			return errResult(err)
+			return 0, err
 		}
 		userID = resolved
-		_ = resolvedName
 	}
+	return userID, nil
+}
+
+type ownerSummary struct {
+	UserID   int    `json:"user_id"`
+	Name     string `json:"name"`
+	Initials string `json:"initials"`
+}
+
+func summarizeOwners(owners []api.StoryOwner) []ownerSummary {
+	out := make([]ownerSummary, len(owners))
+	for i, o := range owners {
+		out[i] = ownerSummary{UserID: o.UserID, Name: o.Name, Initials: o.Initials}
+	}
+	return out
+}
 
-	owners, err := api.WebAddOwner(projectID, storyID, userID)
+func (h *handlers) handleAddOwner(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	if projectID == 0 || storyID == 0 {
+		return errResult(fmt.Errorf("project_id and story_id are required"))
+	}
+	userID, err := h.ownerFromArgs(ctx, req, projectID)
 	if err != nil {
 		return errResult(err)
 	}
 
-	type ownerSummary struct {
-		UserID   int    `json:"user_id"`
-		Name     string `json:"name"`
-		Initials string `json:"initials"`
+	owners, err := h.api.WebAddOwner(ctx, projectID, storyID, userID)
+	if err != nil {
+		return errResult(err)
 	}
-	out := make([]ownerSummary, len(owners))
-	for i, o := range owners {
-		out[i] = ownerSummary{UserID: o.UserID, Name: o.Name, Initials: o.Initials}
+	return textResult(summarizeOwners(owners))
+}
+
+func (h *handlers) handleRemoveLabel(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	label := getString(req, "label")
+	if projectID == 0 || storyID == 0 || label == "" {
+		return errResult(fmt.Errorf("project_id, story_id, and label are required"))
 	}
-	return textResult(out)
+
+	result, err := h.api.WebRemoveLabel(ctx, projectID, storyID, label)
+	if err != nil {
+		return errResult(err)
+	}
+
+	type labelResult struct {
+		ID      int    `json:"id"`
+		Name    string `json:"name"`
+		Removed bool   `json:"removed"`
+	}
+	return textResult(labelResult{ID: result.ID, Name: result.Name, Removed: true})
+}
+
+func (h *handlers) handleRemoveOwner(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectID := getInt(req, "project_id")
+	storyID := getInt(req, "story_id")
+	if projectID == 0 || storyID == 0 {
+		return errResult(fmt.Errorf("project_id and story_id are required"))
+	}
+	userID, err := h.ownerFromArgs(ctx, req, projectID)
+	if err != nil {
+		return errResult(err)
+	}
+
+	owners, err := h.api.WebRemoveOwner(ctx, projectID, storyID, userID)
+	if err != nil {
+		return errResult(err)
+	}
+	return textResult(summarizeOwners(owners))
 }
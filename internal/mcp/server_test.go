@@ -0,0 +1,698 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+	"github.com/MelianLabs/litetracker-mcp/internal/fake"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// newTestClient starts a fake LiteTracker and an MCP client connected in
+// process to a server backed by it.
+func newTestClient(t *testing.T, opts ...func(*api.Options)) (*fake.Server, *client.Client) {
+	t.Helper()
+	srv := fake.NewServer()
+	t.Cleanup(srv.Close)
+
+	o := srv.Options()
+	for _, fn := range opts {
+		fn(&o)
+	}
+	c, err := client.NewInProcessClient(NewServer(api.New(o), Options{}).MCPServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	initialize(t, c)
+	return srv, c
+}
+
+// initialize starts c and completes the MCP handshake.
+func initialize(t *testing.T, c *client.Client) {
+	t.Helper()
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	init.Params.ClientInfo = mcp.Implementation{Name: "test", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, init); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// call invokes a tool and returns its text output, failing the test if the
+// tool reported an error.
+func call(t *testing.T, c *client.Client, name string, args map[string]any) string {
+	t.Helper()
+	text, isErr := callRaw(t, c, name, args)
+	if isErr {
+		t.Fatalf("%s: %s", name, text)
+	}
+	return text
+}
+
+func callRaw(t *testing.T, c *client.Client, name string, args map[string]any) (string, bool) {
+	t.Helper()
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	res, err := c.CallTool(context.Background(), req)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	var sb strings.Builder
+	for _, content := range res.Content {
+		if tc, ok := content.(mcp.TextContent); ok {
+			sb.WriteString(tc.Text)
+		}
+	}
+	return sb.String(), res.IsError
+}
+
+func decodeResult[T any](t *testing.T, text string) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		t.Fatalf("decode %q: %v", text, err)
+	}
+	return v
+}
+
+func TestGetStory(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	est := 3
+	st := srv.AddStory(pid, api.Story{Title: "Login page", Estimate: &est, Labels: []api.Label{{Name: "auth"}}})
+	srv.AddComment(pid, st.ID, fake.UserID, "Looks good")
+	srv.AddTask(pid, st.ID, "Write tests", false)
+
+	got := decodeResult[struct {
+		Name     string   `json:"name"`
+		State    string   `json:"state"`
+		Labels   []string `json:"labels"`
+		Estimate int      `json:"estimate"`
+		Comments []struct {
+			Text string `json:"text"`
+		} `json:"comments"`
+		Tasks []taskSummary `json:"tasks"`
+	}](t, call(t, c, "get_story", map[string]any{"project_id": pid, "story_id": st.ID}))
+
+	if got.Name != "Login page" || got.State != "unstarted" || got.Estimate != 3 {
+		t.Errorf("story = %+v", got)
+	}
+	if len(got.Labels) != 1 || got.Labels[0] != "auth" {
+		t.Errorf("labels = %v, want [auth]", got.Labels)
+	}
+	if len(got.Comments) != 1 || got.Comments[0].Text != "Looks good" {
+		t.Errorf("comments = %+v", got.Comments)
+	}
+	if len(got.Tasks) != 1 || got.Tasks[0].Description != "Write tests" || got.Tasks[0].Position != 1 {
+		t.Errorf("tasks = %+v", got.Tasks)
+	}
+}
+
+func TestGetStoryNotFound(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+
+	text, isErr := callRaw(t, c, "get_story", map[string]any{"project_id": pid, "story_id": 999})
+	if !isErr {
+		t.Fatalf("expected an error, got %s", text)
+	}
+	if !strings.Contains(text, "Hint: story 999") {
+		t.Errorf("error %q does not name the missing story", text)
+	}
+}
+
+func TestListStoriesPagination(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	for _, title := range []string{"One", "Two", "Three"} {
+		srv.AddStory(pid, api.Story{Title: title})
+	}
+
+	type result struct {
+		Stories []struct {
+			Name string `json:"name"`
+		} `json:"stories"`
+		Total      int  `json:"total"`
+		HasMore    bool `json:"has_more"`
+		NextOffset int  `json:"next_offset"`
+	}
+	first := decodeResult[result](t, call(t, c, "list_stories", map[string]any{"project_id": pid, "limit": 2}))
+	if len(first.Stories) != 2 || !first.HasMore || first.NextOffset != 2 || first.Total != 3 {
+		t.Fatalf("first page = %+v", first)
+	}
+	second := decodeResult[result](t, call(t, c, "list_stories", map[string]any{"project_id": pid, "limit": 2, "offset": first.NextOffset}))
+	if len(second.Stories) != 1 || second.Stories[0].Name != "Three" || second.HasMore {
+		t.Errorf("second page = %+v", second)
+	}
+}
+
+func TestRejectStoryWithReason(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	st := srv.AddStory(pid, api.Story{Title: "Checkout", CurrentState: "delivered"})
+
+	call(t, c, "update_story_state", map[string]any{
+		"project_id": pid, "story_id": st.ID, "state": "rejected", "reason": "Button is misaligned",
+	})
+
+	got, _ := srv.Story(pid, st.ID)
+	if got.CurrentState != "rejected" {
+		t.Errorf("state = %q, want rejected", got.CurrentState)
+	}
+	comments := srv.Comments(pid, st.ID)
+	if len(comments) != 1 || comments[0].Text != "Button is misaligned" || comments[0].PersonID != fake.UserID {
+		t.Errorf("comments = %+v", comments)
+	}
+	if srv.Logins() != 1 {
+		t.Errorf("logins = %d, want 1", srv.Logins())
+	}
+}
+
+func TestWebSessionReloginAfterExpiry(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	st := srv.AddStory(pid, api.Story{Title: "Search"})
+
+	call(t, c, "add_label", map[string]any{"project_id": pid, "story_id": st.ID, "label": "backend"})
+	srv.ExpireSessions()
+	call(t, c, "remove_label", map[string]any{"project_id": pid, "story_id": st.ID, "label": "backend"})
+
+	if srv.Logins() != 2 {
+		t.Errorf("logins = %d, want 2", srv.Logins())
+	}
+	got, _ := srv.Story(pid, st.ID)
+	if len(got.Labels) != 0 {
+		t.Errorf("labels = %+v, want none", got.Labels)
+	}
+}
+
+func TestEditCommentRefusesOthers(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	other := srv.AddPerson("Other Person", "OP")
+	st := srv.AddStory(pid, api.Story{Title: "Search"})
+	comment := srv.AddComment(pid, st.ID, other.ID, "original")
+
+	args := map[string]any{"project_id": pid, "story_id": st.ID, "comment_id": comment.ID, "text": "edited"}
+	if text, isErr := callRaw(t, c, "edit_comment", args); !isErr {
+		t.Fatalf("editing someone else's comment succeeded: %s", text)
+	}
+	args["allow_others"] = true
+	call(t, c, "edit_comment", args)
+
+	if got := srv.Comments(pid, st.ID)[0].Text; got != "edited" {
+		t.Errorf("text = %q, want edited", got)
+	}
+}
+
+func TestAddOwnerByName(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	alice := srv.AddPerson("Alice Smith", "AS")
+	srv.AddMember(pid, alice, "member")
+	st := srv.AddStory(pid, api.Story{Title: "Search"})
+
+	owners := decodeResult[[]ownerSummary](t, call(t, c, "add_owner", map[string]any{"project_id": pid, "story_id": st.ID, "name": "alice"}))
+	if len(owners) != 1 || owners[0].UserID != alice.ID || owners[0].Name != "Alice Smith" {
+		t.Errorf("owners = %+v", owners)
+	}
+}
+
+func TestRemoveLabelAndOwner(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	alice := srv.AddPerson("Alice Smith", "AS")
+	bob := srv.AddPerson("Bob Jones", "BJ")
+	srv.AddMember(pid, alice, "member")
+	srv.AddMember(pid, bob, "member")
+	st := srv.AddStory(pid, api.Story{
+		Title:    "Search",
+		Labels:   []api.Label{{Name: "backend"}, {Name: "ui"}},
+		OwnerIDs: []int{alice.ID, bob.ID},
+	})
+
+	call(t, c, "remove_label", map[string]any{"project_id": pid, "story_id": st.ID, "label": "Backend"})
+	owners := decodeResult[[]ownerSummary](t, call(t, c, "remove_owner", map[string]any{"project_id": pid, "story_id": st.ID, "name": "alice"}))
+	if len(owners) != 1 || owners[0].UserID != bob.ID {
+		t.Errorf("owners = %+v, want only Bob", owners)
+	}
+	got, _ := srv.Story(pid, st.ID)
+	if len(got.Labels) != 1 || got.Labels[0].Name != "ui" {
+		t.Errorf("labels = %+v, want [ui]", got.Labels)
+	}
+
+	// Removing what isn't there fails the same way for both
+	for _, tt := range []struct {
+		tool string
+		args map[string]any
+		want string
+	}{
+		{"remove_label", map[string]any{"project_id": pid, "story_id": st.ID, "label": "backend"}, `has no label "backend"`},
+		{"remove_owner", map[string]any{"project_id": pid, "story_id": st.ID, "user_id": alice.ID}, fmt.Sprintf("user %d is not an owner", alice.ID)},
+	} {
+		text, isErr := callRaw(t, c, tt.tool, tt.args)
+		if !isErr || !strings.Contains(text, tt.want) || !strings.Contains(text, "was not found") {
+			t.Errorf("%s again = %q, want a not found error", tt.tool, text)
+		}
+	}
+}
+
+func TestMoveStoryToTopOfBacklog(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	a := srv.AddStory(pid, api.Story{Title: "A"})
+	b := srv.AddStory(pid, api.Story{Title: "B"})
+	d := srv.AddStory(pid, api.Story{Title: "C"})
+	start := time.Now()
+	srv.AddIteration(pid, "backlog", start, start.AddDate(0, 0, 7), 10, a.ID, b.ID, d.ID)
+
+	call(t, c, "move_story", map[string]any{"project_id": pid, "story_id": d.ID, "position": "top", "panel": "backlog"})
+
+	got := srv.StoryOrder(pid)
+	want := []int{d.ID, a.ID, b.ID}
+	if len(got) != 3 || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestMoveStoryToBottomOfLongBacklog(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	st := srv.AddStory(pid, api.Story{Title: "Move me"})
+	start := time.Now()
+	srv.AddIteration(pid, "backlog", start, start.AddDate(0, 0, 7), 10, st.ID)
+	// More iterations than fit on one page, so the bottom is on a later page
+	var last api.Story
+	for i := range 105 {
+		last = srv.AddStory(pid, api.Story{Title: fmt.Sprintf("Story %d", i)})
+		start = start.AddDate(0, 0, 7)
+		srv.AddIteration(pid, "backlog", start, start.AddDate(0, 0, 7), 10, last.ID)
+	}
+
+	call(t, c, "move_story", map[string]any{"project_id": pid, "story_id": st.ID, "position": "bottom", "panel": "backlog"})
+
+	got := srv.StoryOrder(pid)
+	if len(got) != 106 || got[104] != last.ID || got[105] != st.ID {
+		t.Errorf("order ends %v, want [%d %d]", got[len(got)-2:], last.ID, st.ID)
+	}
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	st := srv.AddStory(pid, api.Story{Title: "Search"})
+
+	first := decodeResult[taskSummary](t, call(t, c, "add_task", map[string]any{"project_id": pid, "story_id": st.ID, "description": "first"}))
+	second := decodeResult[taskSummary](t, call(t, c, "add_task", map[string]any{"project_id": pid, "story_id": st.ID, "description": "second"}))
+	call(t, c, "complete_task", map[string]any{"project_id": pid, "story_id": st.ID, "task_id": first.ID})
+	tasks := decodeResult[[]taskSummary](t, call(t, c, "reorder_task", map[string]any{"project_id": pid, "story_id": st.ID, "task_id": second.ID, "position": 1}))
+
+	if len(tasks) != 2 || tasks[0].ID != second.ID || tasks[1].ID != first.ID || !tasks[1].Complete {
+		t.Errorf("tasks = %+v", tasks)
+	}
+}
+
+func TestBulkUpdateStories(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	a := srv.AddStory(pid, api.Story{Title: "A"})
+	b := srv.AddStory(pid, api.Story{Title: "B"})
+
+	got := decodeResult[struct {
+		Succeeded int `json:"succeeded"`
+		Failed    int `json:"failed"`
+	}](t, call(t, c, "bulk_update_stories", map[string]any{
+		"project_id": pid, "story_ids": []any{a.ID, b.ID, 999}, "operation": "add_label", "label": "v2",
+	}))
+
+	if got.Succeeded != 2 || got.Failed != 1 {
+		t.Errorf("result = %+v, want 2 succeeded and 1 failed", got)
+	}
+	for _, id := range []int{a.ID, b.ID} {
+		if st, _ := srv.Story(pid, id); len(st.Labels) != 1 || st.Labels[0].Name != "v2" {
+			t.Errorf("story %d labels = %+v", id, st.Labels)
+		}
+	}
+}
+
+func TestBulkUpdateStoriesIgnoresRepeatedIDs(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	a := srv.AddStory(pid, api.Story{Title: "A"})
+	b := srv.AddStory(pid, api.Story{Title: "B"})
+
+	got := decodeResult[struct {
+		Results []struct {
+			StoryID int  `json:"story_id"`
+			OK      bool `json:"ok"`
+		} `json:"results"`
+	}](t, call(t, c, "bulk_update_stories", map[string]any{
+		"project_id": pid, "story_ids": []any{a.ID, b.ID, a.ID}, "operation": "add_owner", "user_id": fake.UserID,
+	}))
+
+	if len(got.Results) != 2 || got.Results[0].StoryID != a.ID || got.Results[1].StoryID != b.ID {
+		t.Errorf("results = %+v, want one each for %d and %d", got.Results, a.ID, b.ID)
+	}
+	if st, _ := srv.Story(pid, a.ID); len(st.OwnerIDs) != 1 {
+		t.Errorf("story %d owners = %v, want just %d", a.ID, st.OwnerIDs, fake.UserID)
+	}
+}
+
+func TestRetriesTransientFailures(t *testing.T) {
+	srv, c := newTestClient(t, func(o *api.Options) {
+		o.Retry = api.RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	})
+	pid := srv.AddProject("Web")
+
+	srv.FailNext(http.StatusServiceUnavailable, http.StatusBadGateway)
+	projects := decodeResult[[]struct {
+		ID int `json:"id"`
+	}](t, call(t, c, "list_projects", nil))
+
+	if len(projects) != 1 || projects[0].ID != pid {
+		t.Errorf("projects = %+v", projects)
+	}
+	if srv.Requests() != 3 {
+		t.Errorf("requests = %d, want 3", srv.Requests())
+	}
+}
+
+func listTools(t *testing.T, c *client.Client) map[string]mcp.Tool {
+	t.Helper()
+	res, err := c.ListTools(context.Background(), mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools := map[string]mcp.Tool{}
+	for _, tool := range res.Tools {
+		tools[tool.Name] = tool
+	}
+	return tools
+}
+
+func TestToolAnnotations(t *testing.T) {
+	_, c := newTestClient(t)
+	tools := listTools(t, c)
+
+	for name, want := range map[string]struct{ readOnly, destructive, idempotent bool }{
+		"get_me":         {true, false, true},
+		"list_stories":   {true, false, true},
+		"post_comment":   {false, false, false},
+		"create_story":   {false, false, false},
+		"add_label":      {false, false, true},
+		"delete_comment": {false, true, true},
+		"update_story":   {false, true, true},
+	} {
+		a := tools[name].Annotations
+		if a.ReadOnlyHint == nil || a.DestructiveHint == nil || a.IdempotentHint == nil {
+			t.Errorf("%s: annotations missing: %+v", name, a)
+			continue
+		}
+		got := struct{ readOnly, destructive, idempotent bool }{*a.ReadOnlyHint, *a.DestructiveHint, *a.IdempotentHint}
+		if got != want {
+			t.Errorf("%s: hints (readOnly, destructive, idempotent) = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	c, err := client.NewInProcessClient(NewServer(api.New(srv.Options()), Options{ReadOnly: true}).MCPServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	initialize(t, c)
+
+	tools := listTools(t, c)
+	for name, tool := range tools {
+		if !*tool.Annotations.ReadOnlyHint {
+			t.Errorf("read-only server registered %s", name)
+		}
+	}
+	for _, name := range []string{"get_me", "get_story", "get_project_activity"} {
+		if _, ok := tools[name]; !ok {
+			t.Errorf("read-only server is missing %s", name)
+		}
+	}
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "post_comment"
+	req.Params.Arguments = map[string]any{"project_id": 1, "story_id": 1, "text": "hi"}
+	if _, err := c.CallTool(context.Background(), req); err == nil {
+		t.Error("post_comment succeeded on a read-only server")
+	}
+}
+
+func TestErrHint(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"not found", &api.APIError{StatusCode: 404, Resource: "story 456 in project 123"},
+			"Hint: story 456 in project 123 was not found. Check the IDs, e.g. with list_projects or list_stories."},
+		{"not found without resource", fmt.Errorf("fetch: %w", &api.APIError{StatusCode: 404}),
+			"Hint: the requested resource was not found. Check the IDs, e.g. with list_projects or list_stories."},
+		{"label missing from story", fmt.Errorf("%w: story 456 has no label %q", api.ErrNotFound, "backend"),
+			"Hint: the requested resource was not found. Check the IDs, e.g. with list_projects or list_stories."},
+		{"unauthorized", &api.APIError{StatusCode: 401},
+			"Hint: LiteTracker rejected the credentials. Check LITETRACKER_TOKEN, or LITETRACKER_EMAIL and LITETRACKER_PASSWORD for write tools."},
+		{"session expired", fmt.Errorf("%w: run `litetracker login` again", api.ErrSessionExpired),
+			"Hint: the imported browser session has expired. Ask the user to run `litetracker login` again; read-only tools still work."},
+		{"forbidden", fmt.Errorf("add label failed: %w", &api.APIError{StatusCode: 403, Resource: "project 123"}),
+			"Hint: the configured user does not have permission to access project 123."},
+		{"rate limited", &api.APIError{StatusCode: 429, Retryable: true},
+			"Hint: LiteTracker is rate limiting requests. Wait a minute before retrying."},
+		{"outage", &api.APIError{StatusCode: 503, Retryable: true},
+			"Hint: this looks like a temporary LiteTracker outage. Retrying later may succeed."},
+		{"bad request", &api.APIError{StatusCode: 400}, ""},
+		{"other error", fmt.Errorf("project_id is required"), ""},
+	} {
+		if got := errHint(tt.err); got != tt.want {
+			t.Errorf("%s: errHint = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+type dependencyGraph struct {
+	RootID  int `json:"root_id"`
+	Stories []struct {
+		ID            int      `json:"id"`
+		Name          string   `json:"name"`
+		Depth         int      `json:"depth"`
+		BlockedBy     []int    `json:"blocked_by"`
+		OtherBlockers []string `json:"other_blockers"`
+		Error         string   `json:"error"`
+	} `json:"stories"`
+	Truncated bool `json:"truncated"`
+}
+
+func TestDependencyGraphCycle(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	a := srv.AddStory(pid, api.Story{Title: "Checkout"})
+	b := srv.AddStory(pid, api.Story{Title: "Payments"})
+	srv.AddBlocker(pid, a.ID, fmt.Sprintf("Needs #%d", b.ID))
+	srv.AddBlocker(pid, b.ID, fmt.Sprintf("Needs #%d first", a.ID))
+
+	got := decodeResult[dependencyGraph](t, call(t, c, "get_dependency_graph", map[string]any{"project_id": pid, "story_id": a.ID}))
+	if got.Truncated || len(got.Stories) != 2 {
+		t.Fatalf("graph = %+v, want the two stories once each", got)
+	}
+	if s := got.Stories[0]; s.ID != a.ID || s.Depth != 0 || len(s.BlockedBy) != 1 || s.BlockedBy[0] != b.ID {
+		t.Errorf("root = %+v", s)
+	}
+	if s := got.Stories[1]; s.ID != b.ID || s.Depth != 1 || len(s.BlockedBy) != 1 || s.BlockedBy[0] != a.ID {
+		t.Errorf("blocker = %+v", s)
+	}
+}
+
+func TestDependencyGraphLimits(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	// A chain of stories, each blocked by the next
+	chain := make([]api.Story, maxGraphStories+10)
+	for i := range chain {
+		chain[i] = srv.AddStory(pid, api.Story{Title: fmt.Sprintf("Step %d", i)})
+	}
+	for i := range len(chain) - 1 {
+		srv.AddBlocker(pid, chain[i].ID, fmt.Sprintf("Blocked by #%d", chain[i+1].ID))
+	}
+	root := chain[0].ID
+
+	got := decodeResult[dependencyGraph](t, call(t, c, "get_dependency_graph", map[string]any{"project_id": pid, "story_id": root, "max_depth": 100}))
+	if len(got.Stories) != maxGraphStories || !got.Truncated {
+		t.Errorf("got %d stories, truncated %v; want %d, truncated", len(got.Stories), got.Truncated, maxGraphStories)
+	}
+
+	got = decodeResult[dependencyGraph](t, call(t, c, "get_dependency_graph", map[string]any{"project_id": pid, "story_id": root, "max_depth": 2}))
+	if len(got.Stories) != 3 || !got.Truncated || got.Stories[2].Depth != 2 {
+		t.Errorf("max_depth 2: graph = %+v, want 3 stories, truncated", got)
+	}
+}
+
+func TestDependencyGraphUnreachableStories(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	other := srv.AddProject("Mobile")
+	st := srv.AddStory(pid, api.Story{Title: "Checkout"})
+	elsewhere := srv.AddStory(other, api.Story{Title: "App release"})
+	srv.AddBlocker(pid, st.ID, fmt.Sprintf("Waiting on #%d and #999999", elsewhere.ID))
+	srv.AddBlocker(pid, st.ID, "Waiting on design")
+
+	got := decodeResult[dependencyGraph](t, call(t, c, "get_dependency_graph", map[string]any{"project_id": pid, "story_id": st.ID}))
+	if len(got.Stories) != 3 {
+		t.Fatalf("graph = %+v, want the root and two unreachable stories", got)
+	}
+	root := got.Stories[0]
+	if len(root.BlockedBy) != 2 || root.BlockedBy[0] != elsewhere.ID || root.BlockedBy[1] != 999999 {
+		t.Errorf("blocked_by = %v, want [%d 999999]", root.BlockedBy, elsewhere.ID)
+	}
+	if len(root.OtherBlockers) != 1 || root.OtherBlockers[0] != "Waiting on design" {
+		t.Errorf("other_blockers = %v", root.OtherBlockers)
+	}
+	// Stories outside the project, or missing, are reported rather than
+	// failing the whole graph
+	for _, s := range got.Stories[1:] {
+		if s.Error == "" || s.Name != "" {
+			t.Errorf("story %d = %+v, want an error", s.ID, s)
+		}
+	}
+}
+
+func TestUpdateStoryDiff(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	est := 2
+	st := srv.AddStory(pid, api.Story{Title: "Checkout", Description: "Pay with card", Estimate: &est})
+
+	type result struct {
+		ID      int `json:"id"`
+		Changes map[string]struct {
+			Before any `json:"before"`
+			After  any `json:"after"`
+		} `json:"changes"`
+	}
+	got := decodeResult[result](t, call(t, c, "update_story", map[string]any{
+		"project_id": pid, "story_id": st.ID, "title": "Checkout v2", "description": "Pay with card", "estimate": 5,
+	}))
+	// The description was sent unchanged, so it isn't in the diff
+	if len(got.Changes) != 2 {
+		t.Fatalf("changes = %+v, want title and estimate", got.Changes)
+	}
+	if ch := got.Changes["title"]; ch.Before != "Checkout" || ch.After != "Checkout v2" {
+		t.Errorf("title change = %+v", ch)
+	}
+	if ch := got.Changes["estimate"]; ch.Before != float64(2) || ch.After != float64(5) {
+		t.Errorf("estimate change = %+v", ch)
+	}
+
+	// An explicit null removes the estimate
+	got = decodeResult[result](t, call(t, c, "update_story", map[string]any{"project_id": pid, "story_id": st.ID, "estimate": nil}))
+	if ch, ok := got.Changes["estimate"]; !ok || ch.Before != float64(5) || ch.After != nil {
+		t.Errorf("estimate change = %+v, want 5 to null", got.Changes)
+	}
+	if s, _ := srv.Story(pid, st.ID); s.Estimate != nil {
+		t.Errorf("estimate = %d, want none", *s.Estimate)
+	}
+}
+
+func TestGetEpicProgress(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	epic := srv.AddEpic(pid, "Checkout revamp", "checkout")
+	label := []api.Label{{Name: "checkout"}}
+	three, five, two := 3, 5, 2
+	srv.AddStory(pid, api.Story{Title: "Card form", CurrentState: "accepted", Estimate: &three, Labels: label})
+	srv.AddStory(pid, api.Story{Title: "Wallets", CurrentState: "accepted", Estimate: &five, Labels: label})
+	srv.AddStory(pid, api.Story{Title: "Receipts", CurrentState: "started", Estimate: &two, Labels: label})
+	srv.AddStory(pid, api.Story{Title: "Copy review", StoryType: "chore", Labels: label})
+	srv.AddStory(pid, api.Story{Title: "Search", CurrentState: "accepted", Estimate: &five})
+
+	type progress struct {
+		TotalStories    int            `json:"total_stories"`
+		AcceptedStories int            `json:"accepted_stories"`
+		ByState         map[string]int `json:"by_state"`
+		TotalPoints     int            `json:"total_points"`
+		AcceptedPoints  int            `json:"accepted_points"`
+		PercentComplete float64        `json:"percent_complete"`
+	}
+	got := decodeResult[struct {
+		Progress progress `json:"progress"`
+	}](t, call(t, c, "get_epic", map[string]any{"project_id": pid, "epic_id": epic.ID})).Progress
+
+	// Weighted by points: 8 of 10 accepted
+	if got.TotalStories != 4 || got.AcceptedStories != 2 || got.TotalPoints != 10 || got.AcceptedPoints != 8 || got.PercentComplete != 80 {
+		t.Errorf("progress = %+v", got)
+	}
+	if got.ByState["accepted"] != 2 || got.ByState["started"] != 1 || got.ByState["unstarted"] != 1 || len(got.ByState) != 3 {
+		t.Errorf("by_state = %v", got.ByState)
+	}
+
+	// With nothing estimated, progress is by story count
+	unestimated := srv.AddEpic(pid, "Onboarding", "onboarding")
+	label = []api.Label{{Name: "onboarding"}}
+	srv.AddStory(pid, api.Story{Title: "Welcome email", CurrentState: "accepted", Labels: label})
+	for _, title := range []string{"Tour", "Checklist", "Invite team"} {
+		srv.AddStory(pid, api.Story{Title: title, Labels: label})
+	}
+	got = decodeResult[struct {
+		Progress progress `json:"progress"`
+	}](t, call(t, c, "get_epic", map[string]any{"project_id": pid, "epic_id": unestimated.ID})).Progress
+	if got.TotalStories != 4 || got.AcceptedStories != 1 || got.TotalPoints != 0 || got.PercentComplete != 25 {
+		t.Errorf("unestimated progress = %+v", got)
+	}
+}
+
+func TestGetCurrentIteration(t *testing.T) {
+	srv, c := newTestClient(t)
+	pid := srv.AddProject("Web")
+	three, five, eight := 3, 5, 8
+	done := srv.AddStory(pid, api.Story{Title: "Card form", CurrentState: "accepted", Estimate: &three})
+	doing := srv.AddStory(pid, api.Story{Title: "Wallets", CurrentState: "started", Estimate: &five})
+	chore := srv.AddStory(pid, api.Story{Title: "Upgrade Go", StoryType: "chore"})
+	later := srv.AddStory(pid, api.Story{Title: "Receipts", Estimate: &eight})
+	start := time.Now().AddDate(0, 0, -3)
+	srv.AddIteration(pid, "done", start.AddDate(0, 0, -7), start, 9)
+	srv.AddIteration(pid, "current", start, start.AddDate(0, 0, 7), 12.5, done.ID, doing.ID, chore.ID)
+	srv.AddIteration(pid, "backlog", start.AddDate(0, 0, 7), start.AddDate(0, 0, 14), 12.5, later.ID)
+
+	got := decodeResult[struct {
+		Number       int             `json:"number"`
+		Kind         string          `json:"kind"`
+		Velocity     float64         `json:"velocity"`
+		TeamStrength float64         `json:"team_strength"`
+		StoryCount   int             `json:"story_count"`
+		Points       iterationPoints `json:"points"`
+		Stories      []struct {
+			ID int `json:"id"`
+		} `json:"stories"`
+	}](t, call(t, c, "get_current_iteration", map[string]any{"project_id": pid}))
+
+	if got.Number != 2 || got.Kind != "current" || got.Velocity != 12.5 || got.TeamStrength != 1 {
+		t.Errorf("iteration = %+v", got)
+	}
+	// The unestimated chore counts as a story but adds no points
+	if got.StoryCount != 3 || len(got.Stories) != 3 {
+		t.Errorf("story_count = %d, stories = %v, want 3", got.StoryCount, got.Stories)
+	}
+	if want := (iterationPoints{Total: 8, Accepted: 3, Remaining: 5}); got.Points != want {
+		t.Errorf("points = %+v, want %+v", got.Points, want)
+	}
+}
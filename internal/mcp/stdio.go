@@ -0,0 +1,65 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// stdioSessionID is the session ID mcp-go gives the single stdio client.
+const stdioSessionID = "stdio"
+
+// ServeStdio serves s over in and out until ctx is cancelled or in is
+// closed. Subscribe requests are answered here; everything else goes to the
+// mcp-go stdio server.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	w := &lockedWriter{w: out}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(s.filterStdio(in, pw, w))
+	}()
+	return server.NewStdioServer(s.MCPServer).Listen(ctx, pr, w)
+}
+
+// filterStdio copies messages from in to next, answering subscribe requests
+// on w instead of passing them on.
+func (s *Server) filterStdio(in io.Reader, next io.Writer, w io.Writer) error {
+	r := bufio.NewReader(in)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			if resp, ok, _ := s.subs.handleMessage(stdioSessionID, line); ok {
+				data, merr := json.Marshal(resp)
+				if merr != nil {
+					return merr
+				}
+				if _, werr := fmt.Fprintf(w, "%s\n", data); werr != nil {
+					return werr
+				}
+			} else if _, werr := next.Write(line); werr != nil {
+				return werr
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// lockedWriter serializes writes, so responses written here and by the
+// mcp-go stdio server, one Write per message, never interleave.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,256 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// subscriptions tracks the resources each client session has subscribed to
+// and polls project activity to tell them when one changes.
+//
+// mcp-go advertises the subscribe capability but answers resources/subscribe
+// with "method not found", so the transports hand those requests to
+// handleMessage before the MCP server sees them.
+type subscriptions struct {
+	api *api.Client
+	srv *server.MCPServer
+
+	mu        sync.Mutex
+	sessions  map[string]bool                   // IDs of the sessions registered with srv
+	bySession map[string]map[string]watchTarget // session ID -> URI -> target
+	since     map[int]string                    // project ID -> RFC 3339 time activity was last polled up to
+}
+
+// errSessionNotFound is returned for a subscribe request from a session the
+// MCP server doesn't have registered, such as a made-up or closed one.
+var errSessionNotFound = errors.New("session not found")
+
+// watchTarget is what a resource URI covers: a whole project, or one story
+// and its comments.
+type watchTarget struct {
+	projectID int
+	storyID   int // 0 for a project resource
+}
+
+func newSubscriptions(client *api.Client, srv *server.MCPServer) *subscriptions {
+	return &subscriptions{
+		api:       client,
+		srv:       srv,
+		sessions:  map[string]bool{},
+		bySession: map[string]map[string]watchTarget{},
+		since:     map[int]string{},
+	}
+}
+
+// parseWatchTarget matches uri against the resource templates.
+func parseWatchTarget(uri string) (watchTarget, bool) {
+	for _, tpl := range []mcp.ResourceTemplate{projectTemplate, storyTemplate, commentsTemplate} {
+		vars := tpl.URITemplate.Match(uri)
+		if len(vars) == 0 {
+			continue
+		}
+		var t watchTarget
+		t.projectID, _ = strconv.Atoi(vars.Get("project_id").String())
+		if sid := vars.Get("story_id"); sid.Valid() {
+			t.storyID, _ = strconv.Atoi(sid.String())
+			return t, t.projectID > 0 && t.storyID > 0
+		}
+		return t, t.projectID > 0
+	}
+	return watchTarget{}, false
+}
+
+// handleMessage answers a resources/subscribe or resources/unsubscribe
+// request from sessionID. For any other message it returns false, and the
+// caller passes the message on to the MCP server. If sessionID isn't a
+// registered session, the response is an error and so is errSessionNotFound.
+func (sub *subscriptions) handleMessage(sessionID string, raw []byte) (mcp.JSONRPCMessage, bool, error) {
+	var msg struct {
+		ID     *mcp.RequestId `json:"id"`
+		Method mcp.MCPMethod  `json:"method"`
+		Params struct {
+			URI string `json:"uri"`
+		} `json:"params"`
+	}
+	if json.Unmarshal(raw, &msg) != nil || msg.ID == nil {
+		return nil, false, nil
+	}
+	if msg.Method != "resources/subscribe" && msg.Method != "resources/unsubscribe" {
+		return nil, false, nil
+	}
+	if !sub.hasSession(sessionID) {
+		return mcp.NewJSONRPCError(*msg.ID, mcp.INVALID_REQUEST, errSessionNotFound.Error(), nil), true, errSessionNotFound
+	}
+	if msg.Method == "resources/subscribe" {
+		t, ok := parseWatchTarget(msg.Params.URI)
+		if !ok {
+			return mcp.NewJSONRPCError(*msg.ID, mcp.INVALID_PARAMS, "unknown resource URI: "+msg.Params.URI, nil), true, nil
+		}
+		if !sub.subscribe(sessionID, msg.Params.URI, t) {
+			return mcp.NewJSONRPCError(*msg.ID, mcp.INVALID_REQUEST, errSessionNotFound.Error(), nil), true, errSessionNotFound
+		}
+	} else {
+		sub.unsubscribe(sessionID, msg.Params.URI)
+	}
+	return mcp.NewJSONRPCResultResponse(*msg.ID, mcp.EmptyResult{}), true, nil
+}
+
+// addSession records a session the MCP server has registered.
+func (sub *subscriptions) addSession(sessionID string) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	sub.sessions[sessionID] = true
+}
+
+func (sub *subscriptions) hasSession(sessionID string) bool {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	return sub.sessions[sessionID]
+}
+
+// subscribe records the subscription, unless the session has ended since
+// handleMessage checked it.
+func (sub *subscriptions) subscribe(sessionID, uri string, t watchTarget) bool {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	if !sub.sessions[sessionID] {
+		return false
+	}
+	if sub.bySession[sessionID] == nil {
+		sub.bySession[sessionID] = map[string]watchTarget{}
+	}
+	sub.bySession[sessionID][uri] = t
+	if _, ok := sub.since[t.projectID]; !ok {
+		// Only changes from now on are reported
+		sub.since[t.projectID] = time.Now().UTC().Format(time.RFC3339)
+	}
+	return true
+}
+
+func (sub *subscriptions) unsubscribe(sessionID, uri string) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	delete(sub.bySession[sessionID], uri)
+	if len(sub.bySession[sessionID]) == 0 {
+		delete(sub.bySession, sessionID)
+	}
+	sub.forgetUnwatched()
+}
+
+// dropSession forgets a session that has ended, with all its subscriptions.
+func (sub *subscriptions) dropSession(sessionID string) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	delete(sub.sessions, sessionID)
+	delete(sub.bySession, sessionID)
+	sub.forgetUnwatched()
+}
+
+// forgetUnwatched stops polling projects nobody is subscribed to any more.
+// sub.mu must be held.
+func (sub *subscriptions) forgetUnwatched() {
+	for pid := range sub.since {
+		watched := false
+		for _, uris := range sub.bySession {
+			for _, t := range uris {
+				watched = watched || t.projectID == pid
+			}
+		}
+		if !watched {
+			delete(sub.since, pid)
+		}
+	}
+}
+
+// watch polls for changes to subscribed resources every interval until ctx
+// is cancelled. A zero interval disables polling.
+func (sub *subscriptions) watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			sub.poll(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// poll fetches the activity in each watched project since the last poll and
+// notifies the sessions subscribed to a resource it touched.
+func (sub *subscriptions) poll(ctx context.Context) {
+	ctx = api.WithBackgroundPriority(ctx)
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	sub.mu.Lock()
+	since := maps.Clone(sub.since)
+	sub.mu.Unlock()
+
+	for pid, after := range since {
+		activities, err := sub.api.GetProjectActivity(ctx, pid, after)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			slog.Error("subscription poll failed for project", "projectID", pid, "err", err)
+			continue
+		}
+		changed := map[int]bool{}
+		for _, a := range activities {
+			for _, r := range a.PrimaryResources {
+				if r.Kind == "story" {
+					changed[r.ID] = true
+				}
+			}
+		}
+		if len(activities) > 0 {
+			sub.notify(pid, changed)
+		}
+
+		sub.mu.Lock()
+		if _, ok := sub.since[pid]; ok {
+			sub.since[pid] = now
+		}
+		sub.mu.Unlock()
+	}
+}
+
+// notify sends notifications/resources/updated for every subscribed URI in
+// project pid that covers one of the changed stories.
+func (sub *subscriptions) notify(pid int, changed map[int]bool) {
+	type update struct{ sessionID, uri string }
+	var updates []update
+	sub.mu.Lock()
+	for sessionID, uris := range sub.bySession {
+		for uri, t := range uris {
+			if t.projectID == pid && (t.storyID == 0 || changed[t.storyID]) {
+				updates = append(updates, update{sessionID, uri})
+			}
+		}
+	}
+	sub.mu.Unlock()
+
+	for _, u := range updates {
+		err := sub.srv.SendNotificationToSpecificClient(u.sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": u.uri})
+		if err != nil {
+			// Streamable HTTP clients only receive notifications while they
+			// hold a listening stream open
+			slog.Debug("resource update not delivered", "session", u.sessionID, "uri", u.uri, "err", err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+	"github.com/MelianLabs/litetracker-mcp/internal/fake"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// addStoryActivity records a change to story st a minute from now, so it
+// falls after any subscription made by the test.
+func addStoryActivity(srv *fake.Server, pid int, st api.Story, kind string) {
+	srv.AddActivity(pid, api.Activity{
+		Kind:             kind,
+		Message:          "Reviewer changed " + st.Title,
+		OccurredAt:       time.Now().Add(time.Minute).UTC().Format(time.RFC3339),
+		PrimaryResources: []api.ActivityResource{{Kind: "story", ID: st.ID, Name: st.Title}},
+	})
+}
+
+func TestSubscriptionsOverHTTP(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	pid := srv.AddProject("Web")
+	watched := srv.AddStory(pid, api.Story{Title: "Checkout"})
+	other := srv.AddStory(pid, api.Story{Title: "Search"})
+
+	s := NewServer(api.New(srv.Options()), Options{})
+	web := httptest.NewServer(newHTTPHandler(s, []string{testBearer}, context.Background()))
+	defer web.Close()
+	c, err := client.NewStreamableHttpClient(web.URL+"/mcp",
+		transport.WithHTTPHeaders(map[string]string{"Authorization": "Bearer " + testBearer}),
+		transport.WithContinuousListening())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	updated := make(chan string, 10)
+	c.OnNotification(func(n mcp.JSONRPCNotification) {
+		if n.Method == mcp.MethodNotificationResourceUpdated {
+			updated <- fmt.Sprint(n.Params.AdditionalFields["uri"])
+		}
+	})
+	initialize(t, c)
+
+	ctx := context.Background()
+	uri := fmt.Sprintf("litetracker://projects/%d/stories/%d", pid, watched.ID)
+	sub := mcp.SubscribeRequest{}
+	sub.Params.URI = uri
+	if err := c.Subscribe(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	sub.Params.URI = "litetracker://nope"
+	if err := c.Subscribe(ctx, sub); err == nil {
+		t.Error("subscribed to an unknown URI")
+	}
+
+	// A change to another story is not reported
+	addStoryActivity(srv, pid, other, "story_update_activity")
+	s.subs.poll(ctx)
+	select {
+	case got := <-updated:
+		t.Fatalf("got update for %s, want none", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	addStoryActivity(srv, pid, watched, "comment_create_activity")
+	s.subs.poll(ctx)
+	select {
+	case got := <-updated:
+		if got != uri {
+			t.Errorf("updated uri = %s, want %s", got, uri)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no resource update notification")
+	}
+
+	unsub := mcp.UnsubscribeRequest{}
+	unsub.Params.URI = uri
+	if err := c.Unsubscribe(ctx, unsub); err != nil {
+		t.Fatal(err)
+	}
+	s.subs.mu.Lock()
+	n := len(s.subs.bySession) + len(s.subs.since)
+	s.subs.mu.Unlock()
+	if n != 0 {
+		t.Errorf("subscriptions left after unsubscribing: %+v %+v", s.subs.bySession, s.subs.since)
+	}
+}
+
+func TestSubscriptionsOverStdio(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	pid := srv.AddProject("Web")
+	st := srv.AddStory(pid, api.Story{Title: "Checkout"})
+	s := NewServer(api.New(srv.Options()), Options{})
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.ServeStdio(ctx, inR, outW)
+
+	lines := make(chan map[string]any, 10)
+	go func() {
+		sc := bufio.NewScanner(outR)
+		for sc.Scan() {
+			var m map[string]any
+			json.Unmarshal(sc.Bytes(), &m)
+			lines <- m
+		}
+	}()
+	send := func(msg string) {
+		t.Helper()
+		if _, err := io.WriteString(inW, msg+"\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	next := func() map[string]any {
+		t.Helper()
+		select {
+		case m := <-lines:
+			return m
+		case <-time.After(5 * time.Second):
+			t.Fatal("no message from server")
+			return nil
+		}
+	}
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0.0"}}}`)
+	init := next()
+	caps, _ := init["result"].(map[string]any)["capabilities"].(map[string]any)
+	if res, _ := caps["resources"].(map[string]any); res["subscribe"] != true {
+		t.Errorf("resources capability = %v, want subscribe", caps["resources"])
+	}
+	send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	uri := fmt.Sprintf("litetracker://projects/%d/stories/%d/comments", pid, st.ID)
+	send(fmt.Sprintf(`{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":%q}}`, uri))
+	if resp := next(); resp["id"] != float64(2) || resp["error"] != nil {
+		t.Fatalf("subscribe response = %v", resp)
+	}
+
+	addStoryActivity(srv, pid, st, "comment_create_activity")
+	s.subs.poll(ctx)
+	n := next()
+	params, _ := n["params"].(map[string]any)
+	if n["method"] != mcp.MethodNotificationResourceUpdated || params["uri"] != uri {
+		t.Errorf("notification = %v, want resources/updated for %s", n, uri)
+	}
+
+	// Other requests still reach the MCP server
+	send(`{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	if resp := next(); resp["id"] != float64(3) || resp["error"] != nil {
+		t.Errorf("ping response = %v", resp)
+	}
+}
+
+func TestSubscribeUnknownSession(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	pid := srv.AddProject("Web")
+	s := NewServer(api.New(srv.Options()), Options{})
+	web := httptest.NewServer(newHTTPHandler(s, []string{testBearer}, context.Background()))
+	defer web.Close()
+
+	send := func(method, sessionID, body string) int {
+		t.Helper()
+		req, _ := http.NewRequest(method, web.URL+"/mcp", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+testBearer)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(server.HeaderKeySessionID, sessionID)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	c, err := client.NewStreamableHttpClient(web.URL+"/mcp",
+		transport.WithHTTPHeaders(map[string]string{"Authorization": "Bearer " + testBearer}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	initialize(t, c)
+	closed := c.GetSessionId()
+	if status := send(http.MethodDelete, closed, ""); status != http.StatusOK {
+		t.Fatalf("DELETE session: status = %d", status)
+	}
+
+	body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"litetracker://projects/%d"}}`, pid)
+	for _, id := range []string{"mcp-session-made-up", closed} {
+		if status := send(http.MethodPost, id, body); status != http.StatusNotFound {
+			t.Errorf("subscribe from session %q: status = %d, want %d", id, status, http.StatusNotFound)
+		}
+	}
+
+	s.subs.mu.Lock()
+	defer s.subs.mu.Unlock()
+	if len(s.subs.bySession)+len(s.subs.since)+len(s.subs.sessions) != 0 {
+		t.Errorf("subscriptions recorded for unknown sessions: %+v %+v %+v", s.subs.sessions, s.subs.bySession, s.subs.since)
+	}
+}
@@ -1,16 +1,16 @@
 package sync
 
 import (
+	"context"
 	"log/slog"
 	"strings"
 
 	"github.com/MelianLabs/litetracker-mcp/internal/api"
-	"github.com/MelianLabs/litetracker-mcp/internal/config"
 	"github.com/MelianLabs/litetracker-mcp/internal/db"
 )
 
-func fetchAllStories(projectID int, state string) []api.Story {
-	stories, err := api.ListStories(projectID, api.ListStoriesOpts{State: state, Limit: 200})
+func fetchAllStories(ctx context.Context, client *api.Client, projectID int, state string) []api.Story {
+	stories, err := client.ListStories(ctx, projectID, api.ListStoriesOpts{State: state})
 	if err != nil {
 		slog.Error("failed to fetch stories", "projectID", projectID, "state", state, "err", err)
 		return nil
@@ -18,21 +18,31 @@ func fetchAllStories(projectID int, state string) []api.Story {
 	return stories
 }
 
-func isMyStory(story api.Story) bool {
+// Options says which projects to sync and whose stories and mentions to
+// flag.
+type Options struct {
+	ProjectIDs []int
+	// UserID marks the stories this user owns as mine.
+	UserID int
+	// Username marks comments mentioning this user.
+	Username string
+}
+
+func isMyStory(story api.Story, userID int) bool {
 	for _, o := range story.Owners {
-		if o.UserID == config.C.UserID {
+		if o.UserID == userID {
 			return true
 		}
 	}
 	return false
 }
 
-func mentionsUser(text string) bool {
+func mentionsUser(text, username string) bool {
 	if text == "" {
 		return false
 	}
 	lower := strings.ToLower(text)
-	username := strings.ToLower(config.C.Username)
+	username = strings.ToLower(username)
 	return strings.Contains(lower, username) || strings.Contains(lower, "@"+username)
 }
 
@@ -40,20 +50,25 @@ type syncStats struct {
 	Stories  int
 	Mine     int
 	Comments int
+	Tasks    int
+	Blockers int
+	Epics    int
 }
 
-func syncProject(projectID int) syncStats {
+func syncProject(ctx context.Context, client *api.Client, projectID int, opts Options) syncStats {
 	stats := syncStats{}
 
-	states := []string{"started", "unstarted", "delivered", "accepted", "rejected"}
 	var allStories []api.Story
-	for _, state := range states {
-		allStories = append(allStories, fetchAllStories(projectID, state)...)
+	for _, state := range api.StoryStates {
+		allStories = append(allStories, fetchAllStories(ctx, client, projectID, state)...)
+	}
+	if ctx.Err() != nil {
+		return stats
 	}
 
 	myStoryIDs := map[int]bool{}
 	for _, s := range allStories {
-		if isMyStory(s) {
+		if isMyStory(s, opts.UserID) {
 			myStoryIDs[s.ID] = true
 		}
 	}
@@ -116,13 +131,16 @@ func syncProject(projectID int) syncStats {
 
 	// Fetch and sync comments for all stories
 	for _, s := range allStories {
-		comments, err := api.GetStoryComments(projectID, s.ID)
+		if ctx.Err() != nil {
+			return stats
+		}
+		comments, err := client.GetStoryComments(ctx, projectID, s.ID)
 		if err != nil {
 			slog.Error("failed to fetch comments", "storyID", s.ID, "err", err)
 			continue
 		}
 		for _, c := range comments {
-			mentions := mentionsUser(c.Text)
+			mentions := mentionsUser(c.Text, opts.Username)
 			row := db.CommentRow{
 				ID:         c.ID,
 				StoryID:    s.ID,
@@ -151,19 +169,142 @@ func syncProject(projectID int) syncStats {
 		}
 	}
 
+	// Fetch and sync tasks for all stories
+	for _, s := range allStories {
+		if ctx.Err() != nil {
+			return stats
+		}
+		tasks, err := client.ListTasks(ctx, projectID, s.ID)
+		if err != nil {
+			slog.Error("failed to fetch tasks", "storyID", s.ID, "err", err)
+			continue
+		}
+		ids := make([]int, len(tasks))
+		for i, t := range tasks {
+			ids[i] = t.ID
+		}
+		if err := db.DeleteTasksExcept(s.ID, ids); err != nil {
+			slog.Error("delete removed tasks failed", "storyID", s.ID, "err", err)
+		}
+		for _, t := range tasks {
+			row := db.TaskRow{
+				ID:        t.ID,
+				StoryID:   s.ID,
+				ProjectID: projectID,
+				Complete:  t.Complete,
+				Position:  t.Position,
+				CreatedAt: t.CreatedAt,
+				UpdatedAt: t.UpdatedAt,
+			}
+			if t.Description != "" {
+				row.Description = &t.Description
+			}
+
+			if err := db.UpsertTask(row); err != nil {
+				slog.Error("upsert task failed", "taskID", t.ID, "err", err)
+				continue
+			}
+			stats.Tasks++
+		}
+	}
+
+	// Fetch and sync blockers for all stories
+	for _, s := range allStories {
+		if ctx.Err() != nil {
+			return stats
+		}
+		blockers, err := client.ListBlockers(ctx, projectID, s.ID)
+		if err != nil {
+			slog.Error("failed to fetch blockers", "storyID", s.ID, "err", err)
+			continue
+		}
+		ids := make([]int, len(blockers))
+		for i, b := range blockers {
+			ids[i] = b.ID
+		}
+		if err := db.DeleteBlockersExcept(s.ID, ids); err != nil {
+			slog.Error("delete removed blockers failed", "storyID", s.ID, "err", err)
+		}
+		for _, b := range blockers {
+			row := db.BlockerRow{
+				ID:               b.ID,
+				StoryID:          s.ID,
+				ProjectID:        projectID,
+				BlockingStoryIDs: b.BlockingStoryIDs(),
+				Resolved:         b.Resolved,
+				CreatedAt:        b.CreatedAt,
+				UpdatedAt:        b.UpdatedAt,
+			}
+			if b.Description != "" {
+				row.Description = &b.Description
+			}
+
+			if err := db.UpsertBlocker(row); err != nil {
+				slog.Error("upsert blocker failed", "blockerID", b.ID, "err", err)
+				continue
+			}
+			stats.Blockers++
+		}
+	}
+
+	if ctx.Err() != nil {
+		return stats
+	}
+	epics, err := client.ListEpics(ctx, projectID)
+	if err != nil {
+		slog.Error("failed to fetch epics", "projectID", projectID, "err", err)
+		return stats
+	}
+	for _, e := range epics {
+		row := db.EpicRow{
+			ID:        e.ID,
+			ProjectID: projectID,
+			Name:      e.Name,
+			CreatedAt: e.CreatedAt,
+			UpdatedAt: e.UpdatedAt,
+		}
+		if e.Description != "" {
+			row.Description = &e.Description
+		}
+		if e.Label.Name != "" {
+			row.LabelName = &e.Label.Name
+		}
+		if e.URL != "" {
+			row.URL = &e.URL
+		}
+
+		if err := db.UpsertEpic(row); err != nil {
+			slog.Error("upsert epic failed", "epicID", e.ID, "err", err)
+			continue
+		}
+		stats.Epics++
+	}
+
 	return stats
 }
 
-func SyncAllProjects() {
+// SyncAllProjects syncs every project in opts into DuckDB and refreshes
+// the snapshot. If ctx is cancelled it stops at the next request and skips
+// the snapshot, leaving the previous one in place. Its requests yield to
+// interactive MCP tool calls under rate limiting.
+func SyncAllProjects(ctx context.Context, client *api.Client, opts Options) {
 	slog.Info("starting story sync")
+	ctx = api.WithBackgroundPriority(ctx)
 
-	for _, pid := range config.C.ProjectIDs {
-		stats := syncProject(pid)
+	for _, pid := range opts.ProjectIDs {
+		stats := syncProject(ctx, client, pid, opts)
+		if ctx.Err() != nil {
+			slog.Info("story sync cancelled", "projectID", pid)
+			return
+		}
 		slog.Info("synced project",
 			"projectID", pid,
 			"stories", stats.Stories,
 			"mine", stats.Mine,
 			"comments", stats.Comments,
+			"tasks", stats.Tasks,
+			"blockers", stats.Blockers,
+			"epics", stats.Epics,
 		)
 	}
 
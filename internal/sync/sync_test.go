@@ -0,0 +1,170 @@
+package sync
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+	"github.com/MelianLabs/litetracker-mcp/internal/config"
+	"github.com/MelianLabs/litetracker-mcp/internal/db"
+	"github.com/MelianLabs/litetracker-mcp/internal/fake"
+)
+
+func TestSyncAllProjects(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+
+	pid := srv.AddProject("Web")
+	mine := srv.AddStory(pid, api.Story{Title: "Mine", CurrentState: "started", OwnerIDs: []int{fake.UserID}, Labels: []api.Label{{Name: "search"}}})
+	other := srv.AddStory(pid, api.Story{Title: "Other", CurrentState: "accepted"})
+	srv.AddStory(pid, api.Story{Title: "Icebox", CurrentState: "unscheduled"})
+	srv.AddComment(pid, other.ID, fake.UserID, "cc @"+fake.Username)
+	srv.AddTask(pid, mine.ID, "Write tests", true)
+	srv.AddBlocker(pid, mine.ID, "#"+strconv.Itoa(other.ID))
+	srv.AddEpic(pid, "Search", "search")
+
+	config.C = config.Config{DataDir: t.TempDir()}
+	if err := db.InitializeDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	SyncAllProjects(context.Background(), api.New(srv.Options()), Options{ProjectIDs: []int{pid}, UserID: fake.UserID, Username: fake.Username})
+
+	snap, err := sql.Open("duckdb", filepath.Join(config.C.DataDir, "litetracker-snapshot.duckdb")+"?access_mode=read_only")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Close()
+
+	count := func(query string, args ...any) int {
+		t.Helper()
+		var n int
+		if err := snap.QueryRow(query, args...).Scan(&n); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		return n
+	}
+	// Unscheduled stories are not in any synced state.
+	if n := count("SELECT COUNT(*) FROM stories"); n != 2 {
+		t.Errorf("stories = %d, want 2", n)
+	}
+	if n := count("SELECT COUNT(*) FROM stories WHERE is_mine AND id = ?", mine.ID); n != 1 {
+		t.Errorf("story %d not marked as mine", mine.ID)
+	}
+	if n := count("SELECT COUNT(*) FROM stories WHERE mentions_me AND id = ?", other.ID); n != 1 {
+		t.Errorf("story %d not marked as mentioning me", other.ID)
+	}
+	if n := count("SELECT COUNT(*) FROM tasks WHERE complete AND story_id = ?", mine.ID); n != 1 {
+		t.Errorf("tasks for story %d = %d, want 1 complete", mine.ID, n)
+	}
+	if n := count("SELECT COUNT(*) FROM blocked_stories WHERE id = ? AND list_contains(blocking_story_ids, ?)", mine.ID, other.ID); n != 1 {
+		t.Errorf("story %d not blocked by %d", mine.ID, other.ID)
+	}
+	if n := count("SELECT total_stories FROM epic_progress WHERE name = 'Search'"); n != 1 {
+		t.Errorf("epic stories = %d, want 1", n)
+	}
+	if n := count("SELECT COUNT(*) FROM stories WHERE created_at IS NULL"); n != 0 {
+		t.Errorf("%d stories have unparsed created_at", n)
+	}
+}
+
+func TestSyncAllProjectsCancelled(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	pid := srv.AddProject("Web")
+	srv.AddStory(pid, api.Story{Title: "One"})
+
+	config.C = config.Config{DataDir: t.TempDir()}
+	if err := db.InitializeDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	SyncAllProjects(ctx, api.New(srv.Options()), Options{ProjectIDs: []int{pid}, UserID: fake.UserID})
+
+	if matches, _ := filepath.Glob(filepath.Join(config.C.DataDir, "*snapshot*")); len(matches) != 0 {
+		t.Errorf("cancelled sync wrote a snapshot: %v", matches)
+	}
+	if srv.Requests() != 0 {
+		t.Errorf("cancelled sync made %d requests", srv.Requests())
+	}
+}
+
+func TestSyncRemovesDeletedTasks(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	pid := srv.AddProject("Web")
+	st := srv.AddStory(pid, api.Story{Title: "One", CurrentState: "started"})
+	kept := srv.AddTask(pid, st.ID, "Keep me", false)
+	gone := srv.AddTask(pid, st.ID, "Delete me", false)
+
+	config.C = config.Config{DataDir: t.TempDir()}
+	if err := db.InitializeDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	client := api.New(srv.Options())
+	SyncAllProjects(context.Background(), client, Options{ProjectIDs: []int{pid}, UserID: fake.UserID})
+	if err := client.DeleteTask(context.Background(), pid, st.ID, gone.ID); err != nil {
+		t.Fatal(err)
+	}
+	SyncAllProjects(context.Background(), client, Options{ProjectIDs: []int{pid}, UserID: fake.UserID})
+
+	if ids := snapshotIDs(t, "SELECT id FROM tasks WHERE story_id = ?", st.ID); len(ids) != 1 || ids[0] != kept.ID {
+		t.Errorf("synced tasks = %v, want only %d", ids, kept.ID)
+	}
+}
+
+func TestSyncRemovesDeletedBlockers(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	pid := srv.AddProject("Web")
+	blocking := srv.AddStory(pid, api.Story{Title: "Blocking", CurrentState: "started"})
+	st := srv.AddStory(pid, api.Story{Title: "Blocked", CurrentState: "unstarted"})
+	b := srv.AddBlocker(pid, st.ID, "#"+strconv.Itoa(blocking.ID))
+
+	config.C = config.Config{DataDir: t.TempDir()}
+	if err := db.InitializeDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	client := api.New(srv.Options())
+	SyncAllProjects(context.Background(), client, Options{ProjectIDs: []int{pid}, UserID: fake.UserID})
+	srv.RemoveBlocker(pid, st.ID, b.ID)
+	SyncAllProjects(context.Background(), client, Options{ProjectIDs: []int{pid}, UserID: fake.UserID})
+
+	if ids := snapshotIDs(t, "SELECT id FROM blocked_stories"); len(ids) != 0 {
+		t.Errorf("blocked stories = %v after the blocker was removed, want none", ids)
+	}
+}
+
+// snapshotIDs runs query against the sync snapshot and returns the IDs in
+// its single column.
+func snapshotIDs(t *testing.T, query string, args ...any) []int {
+	t.Helper()
+	snap, err := sql.Open("duckdb", filepath.Join(config.C.DataDir, "litetracker-snapshot.duckdb")+"?access_mode=read_only")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Close()
+	rows, err := snap.Query(query, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
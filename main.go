@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -16,37 +20,65 @@ import (
 	mcpserver "github.com/MelianLabs/litetracker-mcp/internal/mcp"
 	"github.com/MelianLabs/litetracker-mcp/internal/notify"
 	ltSync "github.com/MelianLabs/litetracker-mcp/internal/sync"
-
-	"github.com/mark3labs/mcp-go/server"
 )
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: litetracker <serve|daemon|sync>\n")
+		fmt.Fprintf(os.Stderr, "Usage: litetracker <serve|daemon|sync|login>\n")
 		os.Exit(1)
 	}
 
 	switch os.Args[1] {
 	case "serve":
-		runServe()
+		runServe(os.Args[2:])
 	case "daemon":
 		runDaemon()
 	case "sync":
 		runSync()
+	case "login":
+		runLogin(os.Args[2:])
 	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\nUsage: litetracker <serve|daemon|sync>\n", os.Args[1])
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\nUsage: litetracker <serve|daemon|sync|login>\n", os.Args[1])
 		os.Exit(1)
 	}
 }
 
-func runServe() {
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	httpAddr := fs.String("http", "", "serve over streamable HTTP and SSE on this address (e.g. :8080) instead of stdio")
+	readOnly := fs.Bool("read-only", false, "only register tools that don't change LiteTracker data (also LITETRACKER_READ_ONLY=true)")
+	fs.Parse(args)
+
 	if err := config.Init(); err != nil {
 		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
 		os.Exit(1)
 	}
+	// Without a data dir the server still works, it just logs in afresh
+	if err := config.InitDataDir(); err != nil {
+		fmt.Fprintf(os.Stderr, "data dir error, web session will not be saved: %v\n", err)
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		os.Exit(1)
+	}
+	s := mcpserver.NewServer(client, mcpserver.Options{ReadOnly: *readOnly || config.C.ReadOnly})
 
-	s := mcpserver.NewServer()
-	if err := server.ServeStdio(s); err != nil {
+	if *httpAddr != "" && len(config.C.HTTPTokens) == 0 {
+		fmt.Fprintf(os.Stderr, "config error: LITETRACKER_HTTP_TOKENS is required to serve over HTTP\n")
+		os.Exit(1)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go s.WatchSubscriptions(ctx, time.Duration(config.C.WatchIntervalMs)*time.Millisecond)
+
+	if *httpAddr == "" {
+		err = s.ServeStdio(ctx, os.Stdin, os.Stdout)
+	} else {
+		err = mcpserver.ListenAndServe(ctx, s, *httpAddr, config.C.HTTPTokens)
+	}
+	if err != nil && ctx.Err() == nil {
 		fmt.Fprintf(os.Stderr, "server error: %v\n", err)
 		os.Exit(1)
 	}
@@ -92,17 +124,31 @@ func runDaemon() {
 	}
 	slog.Info("DuckDB initialized")
 
+	client, err := newAPIClient()
+	if err != nil {
+		slog.Error("API client setup failed", "err", err)
+		os.Exit(1)
+	}
 	state := loadPollState()
 	slog.Info("loaded state", "lastPoll", state.LastPoll)
 
-	// Initial poll + sync
-	poll(&state)
-	ltSync.SyncAllProjects()
-	slog.Info("initial sync complete")
-
-	// Set up signal handling for clean shutdown
+	// Set up signal handling for clean shutdown. Cancelling ctx aborts any
+	// in-flight poll or sync instead of waiting for it to finish.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigCh
+		slog.Info("received signal, shutting down", "signal", sig)
+		cancel()
+	}()
+
+	// Initial poll + sync
+	syncOpts := newSyncOptions()
+	poll(ctx, client, &state)
+	ltSync.SyncAllProjects(ctx, client, syncOpts)
+	slog.Info("initial sync complete")
 
 	ticker := time.NewTicker(time.Duration(config.C.PollIntervalMs) * time.Millisecond)
 	defer ticker.Stop()
@@ -112,12 +158,11 @@ func runDaemon() {
 	for {
 		select {
 		case <-ticker.C:
-			poll(&state)
+			poll(ctx, client, &state)
 			slog.Info("poll complete", "lastPoll", state.LastPoll)
-			ltSync.SyncAllProjects()
+			ltSync.SyncAllProjects(ctx, client, syncOpts)
 
-		case sig := <-sigCh:
-			slog.Info("received signal, shutting down", "signal", sig)
+		case <-ctx.Done():
 			db.Close()
 			slog.Info("DuckDB closed")
 			return
@@ -144,7 +189,117 @@ func runSync() {
 	}
 	defer db.Close()
 
-	ltSync.SyncAllProjects()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	client, err := newAPIClient()
+	if err != nil {
+		slog.Error("API client setup failed", "err", err)
+		os.Exit(1)
+	}
+	ltSync.SyncAllProjects(ctx, client, newSyncOptions())
+}
+
+// runLogin imports a signed-in browser session for the web client, for
+// accounts that sign in with SSO and have no password to give it.
+func runLogin(args []string) {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	cookie := fs.String("cookie", "", "Cookie header value copied from a signed-in browser tab")
+	cookieFile := fs.String("cookie-file", "", "cookies.txt exported from the browser (- for stdin)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: litetracker login [--cookie HEADER | --cookie-file FILE]\n\n")
+		fmt.Fprintf(os.Stderr, "Without flags, the Cookie header is read from stdin.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if err := config.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := config.InitDataDir(); err != nil {
+		fmt.Fprintf(os.Stderr, "data dir error: %v\n", err)
+		os.Exit(1)
+	}
+
+	data := *cookie
+	if data == "" {
+		var b []byte
+		var err error
+		if *cookieFile != "" && *cookieFile != "-" {
+			b, err = os.ReadFile(*cookieFile)
+		} else {
+			if *cookieFile == "" {
+				fmt.Fprintf(os.Stderr, "Paste the Cookie header from a signed-in %s tab, then press Ctrl-D:\n", config.C.WebURL)
+			}
+			b, err = io.ReadAll(os.Stdin)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "read cookies: %v\n", err)
+			os.Exit(1)
+		}
+		data = string(b)
+	}
+	cookies, err := api.ParseCookies(data, config.C.WebURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read cookies: %v\n", err)
+		os.Exit(1)
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		os.Exit(1)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := client.ImportWebSession(ctx, cookies); err != nil {
+		fmt.Fprintf(os.Stderr, "login failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Browser session saved to %s\n", config.C.SessionFile)
+}
+
+// newAPIClient builds a LiteTracker client from the loaded configuration.
+// With LITETRACKER_CASSETTE set, its traffic is recorded to or replayed from
+// the cassette directory.
+func newAPIClient() (*api.Client, error) {
+	var transport http.RoundTripper
+	if config.C.CassetteMode != "" {
+		var err error
+		transport, err = api.NewCassetteTransport(config.C.CassetteMode, config.C.CassetteDir, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return api.New(api.Options{
+		Token:       config.C.Token,
+		BaseURL:     config.C.BaseURL,
+		WebURL:      config.C.WebURL,
+		Email:       config.C.Email,
+		Password:    config.C.Password,
+		TOTPSecret:  config.C.TOTPSecret,
+		UserID:      config.C.UserID,
+		SessionFile: config.C.SessionFile,
+		Retry: api.RetryPolicy{
+			MaxRetries: config.C.RetryMax,
+			BaseDelay:  time.Duration(config.C.RetryBaseMs) * time.Millisecond,
+			MaxDelay:   time.Duration(config.C.RetryMaxMs) * time.Millisecond,
+		},
+		RateLimitRPS:   config.C.RateLimitRPS,
+		RateLimitBurst: config.C.RateLimitBurst,
+		Transport:      transport,
+	}), nil
+}
+
+// newSyncOptions builds the DuckDB sync settings from the loaded
+// configuration.
+func newSyncOptions() ltSync.Options {
+	return ltSync.Options{
+		ProjectIDs: config.C.ProjectIDs,
+		UserID:     config.C.UserID,
+		Username:   config.C.Username,
+	}
 }
 
 // --- Poll state ---
@@ -174,12 +329,20 @@ func savePollState(s pollState) {
 	_ = os.WriteFile(pollStatePath(), data, 0o644)
 }
 
-func poll(state *pollState) {
+// sendNotification shows a desktop notification; tests replace it.
+var sendNotification = notify.Send
+
+func poll(ctx context.Context, client *api.Client, state *pollState) {
 	since := state.LastPoll
 	now := time.Now().UTC().Format(time.RFC3339)
+	ctx = api.WithBackgroundPriority(ctx)
 
 	for _, pid := range config.C.ProjectIDs {
-		activities, err := api.GetProjectActivity(pid, since)
+		activities, err := client.GetProjectActivity(ctx, pid, since)
+		if ctx.Err() != nil {
+			// Keep LastPoll where it was so the next run picks this window up again
+			return
+		}
 		if err != nil {
 			slog.Error("poll failed for project", "projectID", pid, "err", err)
 			continue
@@ -217,7 +380,7 @@ func poll(state *pollState) {
 				body := performer + ": " + activity.Message
 
 				slog.Info("notification triggered", "kind", activity.Kind, "message", activity.Message)
-				notify.Send(title, body)
+				sendNotification(title, body)
 			}
 		}
 	}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/MelianLabs/litetracker-mcp/internal/api"
+	"github.com/MelianLabs/litetracker-mcp/internal/config"
+	"github.com/MelianLabs/litetracker-mcp/internal/fake"
+)
+
+func TestPoll(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+
+	pid := srv.AddProject("Web")
+	other := srv.AddPerson("Other Person", "OP")
+	st := srv.AddStory(pid, api.Story{Title: "Checkout"})
+	resource := []api.ActivityResource{{Kind: "story", ID: st.ID, Name: st.Title}}
+
+	since := time.Now().Add(-time.Hour).UTC()
+	at := func(d time.Duration) string { return since.Add(d).Format(time.RFC3339) }
+	srv.AddActivity(pid, api.Activity{Kind: "story_update_activity", Message: "Other Person mentioned DEV", PerformedBy: other, OccurredAt: at(-time.Minute), PrimaryResources: resource})
+	srv.AddActivity(pid, api.Activity{Kind: "story_update_activity", Message: "Other Person asked dev to review", PerformedBy: other, OccurredAt: at(time.Minute), PrimaryResources: resource})
+	srv.AddActivity(pid, api.Activity{Kind: "story_update_activity", Message: "Other Person estimated this feature", PerformedBy: other, OccurredAt: at(2 * time.Minute), PrimaryResources: resource})
+	srv.AddActivity(pid, api.Activity{Kind: "comment_create_activity", Message: "Other Person added comment", PerformedBy: other, OccurredAt: at(3 * time.Minute), PrimaryResources: resource})
+
+	config.C = config.Config{ProjectIDs: []int{pid}, Username: fake.Username, DataDir: t.TempDir()}
+	type notification struct{ title, body string }
+	var got []notification
+	defer func(orig func(string, string)) { sendNotification = orig }(sendNotification)
+	sendNotification = func(title, body string) { got = append(got, notification{title, body}) }
+
+	state := pollState{LastPoll: since.Format(time.RFC3339)}
+	poll(context.Background(), api.New(srv.Options()), &state)
+
+	want := []notification{
+		{"[Checkout]", "Other Person: Other Person asked dev to review"},
+		{"[Checkout]", "Other Person: Other Person added comment"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("notifications = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("notification %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if state.LastPoll <= since.Format(time.RFC3339) {
+		t.Errorf("LastPoll = %s, not advanced past %s", state.LastPoll, since.Format(time.RFC3339))
+	}
+	if saved := loadPollState(); saved != state {
+		t.Errorf("saved state = %+v, want %+v", saved, state)
+	}
+	if _, err := os.Stat(pollStatePath()); err != nil {
+		t.Errorf("poll state not written: %v", err)
+	}
+}
+
+func TestPollKeepsWindowWhenCancelled(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	pid := srv.AddProject("Web")
+
+	config.C = config.Config{ProjectIDs: []int{pid}, Username: fake.Username, DataDir: t.TempDir()}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	state := pollState{LastPoll: "2026-01-01T00:00:00Z"}
+	poll(ctx, api.New(srv.Options()), &state)
+
+	if state.LastPoll != "2026-01-01T00:00:00Z" {
+		t.Errorf("LastPoll = %s, want it unchanged", state.LastPoll)
+	}
+	if _, err := os.Stat(pollStatePath()); !os.IsNotExist(err) {
+		t.Errorf("cancelled poll wrote state: %v", err)
+	}
+}